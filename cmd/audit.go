@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/file"
+	"github.com/Ionian-Web3-Storage/ionian-client/node"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditArgs struct {
+		nodes   []string
+		root    string
+		size    int64
+		samples int
+		timeout time.Duration
+		report  string
+	}
+
+	auditCmd = &cobra.Command{
+		Use:   "audit",
+		Short: "Audit file availability on storage nodes by sampling random segments with merkle proof",
+		Run:   audit,
+	}
+)
+
+func init() {
+	auditCmd.Flags().StringSliceVar(&auditArgs.nodes, "node", []string{}, "Ionian storage node URL. Multiple nodes could be specified and separated by comma, e.g. url1,url2,url3")
+	auditCmd.MarkFlagRequired("node")
+	auditCmd.Flags().StringVar(&auditArgs.root, "root", "", "Merkle root of file to audit")
+	auditCmd.MarkFlagRequired("root")
+	auditCmd.Flags().Int64Var(&auditArgs.size, "file-size", 0, "Trusted file size in bytes to validate merkle proofs, default to the size agreed by majority of storage nodes")
+	auditCmd.Flags().IntVar(&auditArgs.samples, "samples", 10, "Number of random segments to sample on each storage node")
+	auditCmd.Flags().DurationVar(&auditArgs.timeout, "timeout", 30*time.Second, "Timeout to download a sampled segment")
+	auditCmd.Flags().StringVar(&auditArgs.report, "report", "", "CSV file to write the audit result of each storage node, default to stdout")
+
+	rootCmd.AddCommand(auditCmd)
+}
+
+func audit(*cobra.Command, []string) {
+	nodes := node.MustNewClients(auditArgs.nodes)
+	for _, client := range nodes {
+		defer client.Close()
+	}
+
+	auditor := file.NewAuditor(nodes...).WithOption(file.AuditOption{
+		Samples: auditArgs.samples,
+		Timeout: auditArgs.timeout,
+		Size:    auditArgs.size,
+	})
+
+	audits, err := auditor.Audit(context.Background(), ethCommon.HexToHash(auditArgs.root))
+	if audits == nil {
+		logrus.WithError(err).Fatal("Failed to audit file")
+	}
+
+	records := [][]string{{"node", "samples", "available", "availability", "avg_latency", "max_latency", "error"}}
+	var unavailable int
+
+	for _, v := range audits {
+		var errMsg string
+		if v.Error != nil {
+			errMsg = v.Error.Error()
+		} else {
+			// report the first failed sample
+			for _, sample := range v.Samples {
+				if sample.Error != nil {
+					errMsg = fmt.Sprintf("segment %v: %v", sample.Segment, sample.Error.Error())
+					break
+				}
+			}
+		}
+
+		if len(errMsg) > 0 {
+			unavailable++
+		}
+
+		records = append(records, []string{
+			v.Node,
+			strconv.Itoa(len(v.Samples)),
+			strconv.Itoa(v.Available()),
+			strconv.FormatFloat(v.Availability(), 'f', 4, 64),
+			v.AvgLatency().String(),
+			v.MaxLatency().String(),
+			errMsg,
+		})
+	}
+
+	if err := writeCSV(auditArgs.report, records); err != nil {
+		logrus.WithError(err).Fatal("Failed to write audit report")
+	}
+
+	if unavailable > 0 {
+		logrus.WithFields(logrus.Fields{
+			"total":       len(audits),
+			"unavailable": unavailable,
+		}).Fatal("File not fully available on some storage nodes")
+	}
+
+	logrus.WithField("total", len(audits)).Info("File available on all storage nodes")
+}
@@ -1,8 +1,16 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/Ionian-Web3-Storage/ionian-client/file"
 	"github.com/Ionian-Web3-Storage/ionian-client/node"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -10,9 +18,28 @@ import (
 var (
 	downloadArgs struct {
 		file  string
+		dir   string
+		batch string
 		nodes []string
 		root  string
+		txSeq uint64
 		proof bool
+		parts bool
+
+		offset int64
+		length int64
+
+		maxRetries    int
+		retryInterval time.Duration
+
+		concurrency int
+		adaptive    bool
+		bandwidth   int64
+
+		encryption encryptionArgs
+
+		batchConcurrency uint
+		batchReport      string
 	}
 
 	downloadCmd = &cobra.Command{
@@ -24,22 +51,209 @@ var (
 
 func init() {
 	downloadCmd.Flags().StringVar(&downloadArgs.file, "file", "", "File name to download")
-	downloadCmd.MarkFlagRequired("file")
+	downloadCmd.Flags().StringVar(&downloadArgs.dir, "dir", "", "Folder to download all files by the manifest root")
+	downloadCmd.Flags().StringVar(&downloadArgs.batch, "batch", "", "File that lists files to download in batch, one root,output[,size] per line")
 	downloadCmd.Flags().StringSliceVar(&downloadArgs.nodes, "node", []string{}, "Ionian storage node URL. Multiple nodes could be specified and separated by comma, e.g. url1,url2,url3")
 	downloadCmd.MarkFlagRequired("node")
 	downloadCmd.Flags().StringVar(&downloadArgs.root, "root", "", "Merkle root to download file")
-	downloadCmd.MarkFlagRequired("root")
+	downloadCmd.Flags().Uint64Var(&downloadArgs.txSeq, "tx-seq", 0, "Transaction sequence number to download file, instead of merkle root")
 	downloadCmd.Flags().BoolVar(&downloadArgs.proof, "proof", false, "Whether to download with merkle proof for validation")
+	downloadCmd.Flags().BoolVar(&downloadArgs.parts, "parts", false, "Whether to download and reassemble all parts if the downloaded file is a parts manifest")
+
+	downloadCmd.Flags().Int64Var(&downloadArgs.offset, "offset", 0, "Offset in bytes to download a range of file")
+	downloadCmd.Flags().Int64Var(&downloadArgs.length, "length", 0, "Length in bytes to download a range of file, default to the end of file")
+	downloadCmd.Flags().IntVar(&downloadArgs.maxRetries, "max-retries", 5, "Max number of retries to download a segment, at least try all storage nodes")
+	downloadCmd.Flags().DurationVar(&downloadArgs.retryInterval, "retry-interval", time.Second, "Backoff interval for the first retry, doubled for each retry")
+	downloadCmd.Flags().IntVar(&downloadArgs.concurrency, "concurrency", 1, "Max number of in-flight requests per storage node")
+	downloadCmd.Flags().BoolVar(&downloadArgs.adaptive, "adaptive", false, "Whether to adjust in-flight requests per storage node based on latency and errors, up to --concurrency")
+	downloadCmd.Flags().Int64Var(&downloadArgs.bandwidth, "bandwidth", 0, "Max download bandwidth in bytes per second, 0 for unlimited")
+	downloadArgs.encryption.addFlags(downloadCmd, "decrypt", "Whether to decrypt file on client side once downloaded")
+
+	downloadCmd.Flags().UintVar(&downloadArgs.batchConcurrency, "batch-concurrency", 4, "Number of files to download in parallel in batch")
+	downloadCmd.Flags().StringVar(&downloadArgs.batchReport, "batch-report", "", "CSV file to write the result of each file in batch, default to stdout")
 
 	rootCmd.AddCommand(downloadCmd)
 }
 
-func download(*cobra.Command, []string) {
+func download(cmd *cobra.Command, _ []string) {
+	if countNonEmpty(downloadArgs.file, downloadArgs.dir, downloadArgs.batch) != 1 {
+		logrus.Fatal("Either --file, --dir or --batch should be specified")
+	}
+
+	byTxSeq := cmd.Flags().Changed("tx-seq")
+	if downloadArgs.batch != "" {
+		if downloadArgs.root != "" || byTxSeq {
+			logrus.Fatal("--root and --tx-seq not allowed in batch")
+		}
+	} else if (downloadArgs.root == "") != byTxSeq {
+		logrus.Fatal("Either --root or --tx-seq should be specified")
+	}
+
 	nodes := node.MustNewClients(downloadArgs.nodes)
 
-	downloader := file.NewDownloader(nodes...)
+	downloader := file.NewDownloader(nodes...).
+		WithProgress(newProgressBar()).
+		WithRetry(file.RetryOption{
+			MaxRetries: downloadArgs.maxRetries,
+			Interval:   downloadArgs.retryInterval,
+		}).
+		WithConcurrency(downloadArgs.concurrency, downloadArgs.adaptive).
+		WithBandwidth(downloadArgs.bandwidth).
+		WithParts(downloadArgs.parts).
+		WithDecryption(downloadArgs.encryption.mustLoadKey())
+
+	if downloadArgs.batch != "" {
+		// progress bar is not rendered for files downloaded in parallel
+		downloader.WithProgress(nil)
+		downloadBatch(downloader)
+		return
+	}
+
+	if byTxSeq {
+		downloadByTxSeq(downloader)
+		return
+	}
+
+	if downloadArgs.offset > 0 || downloadArgs.length > 0 {
+		downloadRange(downloader)
+		return
+	}
+
+	if downloadArgs.dir != "" {
+		if err := downloader.DownloadDir(context.Background(), downloadArgs.root, downloadArgs.dir, downloadArgs.proof); err != nil {
+			logrus.WithError(err).Fatal("Failed to download folder")
+		}
+
+		return
+	}
 
 	if err := downloader.Download(downloadArgs.root, downloadArgs.file, downloadArgs.proof); err != nil {
 		logrus.WithError(err).Fatal("Failed to download file")
 	}
 }
+
+func downloadByTxSeq(downloader *file.Downloader) {
+	if downloadArgs.file == "" {
+		logrus.Fatal("--file required to download file by tx seq")
+	}
+
+	if downloadArgs.offset > 0 || downloadArgs.length > 0 {
+		logrus.Fatal("Range download by tx seq not supported")
+	}
+
+	if err := downloader.DownloadByTxSeq(downloadArgs.txSeq, downloadArgs.file, downloadArgs.proof); err != nil {
+		logrus.WithError(err).Fatal("Failed to download file")
+	}
+}
+
+func downloadRange(downloader *file.Downloader) {
+	if downloadArgs.file == "" {
+		logrus.Fatal("--file required to download a range of file")
+	}
+
+	// download to the end of file by default
+	length := downloadArgs.length
+	if length == 0 {
+		length = -1
+	}
+
+	// never overwrite an existing file, same as downloading the whole file
+	output, err := os.OpenFile(downloadArgs.file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		logrus.WithField("file", downloadArgs.file).Fatal("File already exists")
+	}
+
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to create output file")
+	}
+
+	err = downloader.DownloadRange(context.Background(), downloadArgs.root, downloadArgs.offset, length, output, downloadArgs.proof)
+
+	if closeErr := output.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(downloadArgs.file)
+		logrus.WithError(err).Fatal("Failed to download file range")
+	}
+}
+
+func downloadBatch(downloader *file.Downloader) {
+	lines, err := readLines(downloadArgs.batch)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to read batch file")
+	}
+
+	requests := make([]file.DownloadRequest, 0, len(lines))
+	for i, line := range lines {
+		request, err := parseDownloadRequest(line)
+		if err != nil {
+			logrus.WithError(err).WithField("line", i+1).Fatal("Invalid line in batch file")
+		}
+
+		requests = append(requests, request)
+	}
+
+	results, err := downloader.DownloadMany(context.Background(), requests, downloadArgs.batchConcurrency, downloadArgs.proof)
+	if err != nil {
+		logrus.WithError(err).Error("Batch download terminated")
+	}
+
+	var failed int
+	records := [][]string{{"root", "file", "status", "error"}}
+
+	for _, v := range results {
+		if v.Status == file.BatchStatusFailed {
+			failed++
+		}
+
+		records = append(records, []string{v.Root.Hex(), v.File, v.Status, v.Error})
+	}
+
+	if err = writeCSV(downloadArgs.batchReport, records); err != nil {
+		logrus.WithError(err).Fatal("Failed to write batch report")
+	}
+
+	if failed > 0 {
+		logrus.WithFields(logrus.Fields{
+			"total":  len(results),
+			"failed": failed,
+		}).Fatal("Failed to download some files in batch")
+	}
+
+	logrus.WithField("total", len(results)).Info("Succeeded to download files in batch")
+}
+
+// parseDownloadRequest parses line in format of root,output[,size].
+func parseDownloadRequest(line string) (file.DownloadRequest, error) {
+	fields := strings.Split(line, ",")
+	if len(fields) < 2 || len(fields) > 3 {
+		return file.DownloadRequest{}, errors.Errorf("Invalid number of fields %v", len(fields))
+	}
+
+	root := strings.TrimSpace(fields[0])
+	if len(root) != 66 || !strings.HasPrefix(root, "0x") {
+		return file.DownloadRequest{}, errors.Errorf("Invalid root %v", root)
+	}
+
+	request := file.DownloadRequest{
+		Root:     ethCommon.HexToHash(root),
+		Filename: strings.TrimSpace(fields[1]),
+	}
+
+	if len(request.Filename) == 0 {
+		return file.DownloadRequest{}, errors.New("Output file not specified")
+	}
+
+	if len(fields) == 3 {
+		size, err := strconv.ParseInt(strings.TrimSpace(fields[2]), 10, 64)
+		if err != nil || size <= 0 {
+			return file.DownloadRequest{}, errors.Errorf("Invalid size %v", fields[2])
+		}
+
+		request.Size = size
+	}
+
+	return request, nil
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/file/encryption"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// passphraseEnv is the environment variable of passphrase for client side encryption, which is
+// not accepted as a flag value to avoid being leaked in process list or shell history.
+const passphraseEnv = "IONIAN_ENCRYPTION_PASSPHRASE"
+
+type encryptionArgs struct {
+	flag           string // flag to enable encryption explicitly, e.g. encrypt or decrypt
+	enabled        bool
+	keyFile        string
+	passphraseFile string
+}
+
+func (args *encryptionArgs) addFlags(cmd *cobra.Command, flag, usage string) {
+	args.flag = flag
+
+	cmd.Flags().BoolVar(&args.enabled, flag, false, usage)
+	cmd.Flags().StringVar(&args.keyFile, "encryption-key-file", "", "File of 32 bytes raw key (binary or hex) for client side encryption, requires --"+flag)
+	cmd.Flags().StringVar(&args.passphraseFile, "encryption-passphrase-file", "", "File of passphrase to derive key for client side encryption, or specify passphrase via env "+passphraseEnv+", requires --"+flag)
+}
+
+// mustLoadPassphrase loads the passphrase from file, or environment variable if file not specified.
+func (args *encryptionArgs) mustLoadPassphrase() string {
+	if args.passphraseFile == "" {
+		return os.Getenv(passphraseEnv)
+	}
+
+	content, err := os.ReadFile(args.passphraseFile)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to read passphrase file")
+	}
+
+	passphrase := strings.TrimRight(string(content), "\r\n")
+	if passphrase == "" {
+		logrus.Fatal("Empty passphrase file")
+	}
+
+	return passphrase
+}
+
+// mustLoadKey loads the encryption key from flags, and returns nil if encryption not enabled explicitly.
+func (args *encryptionArgs) mustLoadKey() *encryption.Key {
+	if !args.enabled {
+		if args.keyFile != "" || args.passphraseFile != "" {
+			logrus.Fatalf("--%v required for client side encryption", args.flag)
+		}
+
+		return nil
+	}
+
+	var key *encryption.Key
+	var err error
+
+	if args.keyFile != "" {
+		if args.passphraseFile != "" {
+			logrus.Fatal("Either --encryption-key-file or --encryption-passphrase-file should be specified")
+		}
+
+		key, err = encryption.LoadKeyFile(args.keyFile)
+	} else if passphrase := args.mustLoadPassphrase(); passphrase != "" {
+		key, err = encryption.NewPassphraseKey(passphrase)
+	} else {
+		logrus.Fatalf("Either --encryption-key-file, --encryption-passphrase-file or env %v should be specified", passphraseEnv)
+	}
+
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load encryption key")
+	}
+
+	return key
+}
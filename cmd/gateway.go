@@ -8,7 +8,8 @@ import (
 
 var (
 	gatewayArgs struct {
-		nodes []string
+		nodes   []string
+		noCache bool
 	}
 
 	gatewayCmd = &cobra.Command{
@@ -25,11 +26,14 @@ func init() {
 		"http://127.0.0.1:5680",
 	}, "Storage node list separated by comma")
 	gatewayCmd.Flags().StringVar(&gateway.LocalFileRepo, "repo", "", "Local file repository")
+	gatewayCmd.Flags().BoolVar(&gatewayArgs.noCache, "no-cache", false, "Always calculate merkle root of local files without cached segment roots")
 
 	rootCmd.AddCommand(gatewayCmd)
 }
 
 func startGateway(*cobra.Command, []string) {
+	gateway.LocalRootCache = newRootCache(gatewayArgs.noCache)
+
 	nodes := node.MustNewClients(gatewayArgs.nodes)
 	gateway.MustServeLocal(nodes)
 }
@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"math/rand"
 	"os"
+	"runtime"
 	"time"
 
 	"github.com/Ionian-Web3-Storage/ionian-client/file"
@@ -13,9 +14,11 @@ import (
 
 var (
 	genFileArgs struct {
-		size      uint64
-		file      string
-		overwrite bool
+		size        uint64
+		file        string
+		overwrite   bool
+		hashWorkers int
+		cache       bool
 	}
 
 	genFileCmd = &cobra.Command{
@@ -29,6 +32,8 @@ func init() {
 	genFileCmd.Flags().Uint64Var(&genFileArgs.size, "size", 0, "File size in bytes")
 	genFileCmd.Flags().StringVar(&genFileArgs.file, "file", "tmp123456", "File name to generate")
 	genFileCmd.Flags().BoolVar(&genFileArgs.overwrite, "overwrite", true, "Whether to overwrite existing file")
+	genFileCmd.Flags().BoolVar(&genFileArgs.cache, "cache", false, "Whether to cache segment roots of generated file")
+	genFileCmd.Flags().IntVar(&genFileArgs.hashWorkers, "hash-workers", runtime.NumCPU(), "Number of routines to calculate file merkle root in parallel")
 
 	rootCmd.AddCommand(genFileCmd)
 }
@@ -71,10 +76,10 @@ func generateTempFile(*cobra.Command, []string) {
 		logrus.WithError(err).Fatal("Failed to open file")
 	}
 
-	tree, err := file.MerkleTree()
+	root, err := file.WithHashWorkers(genFileArgs.hashWorkers).WithRootCache(newRootCache(!genFileArgs.cache)).MerkleRoot()
 	if err != nil {
-		logrus.WithError(err).Fatal("Failed to generate merkle tree")
+		logrus.WithError(err).Fatal("Failed to calculate merkle root")
 	}
 
-	logrus.WithField("root", tree.Root()).Info("Succeeded to write file")
+	logrus.WithField("root", root).Info("Succeeded to write file")
 }
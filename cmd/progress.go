@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/file"
+)
+
+const (
+	progressBarWidth          = 40
+	progressBarRenderInterval = 200 * time.Millisecond
+)
+
+// progressBar renders the progress of file upload or download in terminal.
+type progressBar struct {
+	out io.Writer
+
+	phase      file.Phase
+	start      time.Time // time when current phase started
+	startBytes int64     // bytes transferred when current phase started
+	lastRender time.Time
+	finished   bool
+}
+
+// newProgressBar returns a progress listener to render progress bar if stderr is a terminal.
+// Otherwise, returns nil.
+func newProgressBar() file.ProgressListener {
+	info, err := os.Stderr.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+
+	return &progressBar{out: os.Stderr}
+}
+
+// OnProgress implements the file.ProgressListener interface.
+func (bar *progressBar) OnProgress(progress file.Progress) {
+	if progress.Phase != bar.phase {
+		// terminate the unfinished progress bar line
+		if isTransferPhase(bar.phase) && !bar.finished {
+			fmt.Fprintln(bar.out)
+		}
+
+		bar.phase = progress.Phase
+		bar.start = progress.Time
+		bar.startBytes = progress.Bytes
+		bar.lastRender = time.Time{}
+		bar.finished = false
+	}
+
+	if !isTransferPhase(progress.Phase) || bar.finished {
+		return
+	}
+
+	finished := progress.Segments >= progress.TotalSegments
+	if !finished && progress.Time.Sub(bar.lastRender) < progressBarRenderInterval {
+		return
+	}
+
+	bar.render(progress)
+	bar.lastRender = progress.Time
+
+	if finished {
+		fmt.Fprintln(bar.out)
+		bar.finished = true
+	}
+}
+
+func (bar *progressBar) render(progress file.Progress) {
+	var ratio float64
+	if progress.TotalBytes > 0 {
+		ratio = float64(progress.Bytes) / float64(progress.TotalBytes)
+	}
+
+	var speed float64
+	if elapsed := progress.Time.Sub(bar.start).Seconds(); elapsed > 0 {
+		speed = float64(progress.Bytes-bar.startBytes) / elapsed
+	}
+
+	filled := int(ratio * progressBarWidth)
+
+	fmt.Fprintf(bar.out, "\r%-11v [%v%v] %5.1f%% %v/%v segments %v/s",
+		progress.Phase,
+		strings.Repeat("=", filled),
+		strings.Repeat(" ", progressBarWidth-filled),
+		ratio*100,
+		progress.Segments,
+		progress.TotalSegments,
+		formatBytes(speed),
+	)
+}
+
+func isTransferPhase(phase file.Phase) bool {
+	return phase == file.PhaseUploading || phase == file.PhaseDownloading
+}
+
+func formatBytes(bytes float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+
+	i := 0
+	for ; bytes >= 1024 && i < len(units)-1; i++ {
+		bytes /= 1024
+	}
+
+	return fmt.Sprintf("%.1f %v", bytes, units[i])
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/file"
+	"github.com/Ionian-Web3-Storage/ionian-client/file/merkle"
+	"github.com/Ionian-Web3-Storage/ionian-client/node"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	proofFormatJSON   = "json"
+	proofFormatBinary = "binary"
+)
+
+// encodeProofExport encodes exported merkle proof in the specified format, JSON or binary.
+func encodeProofExport(export *file.ProofExport, format string) ([]byte, error) {
+	if format == proofFormatBinary {
+		return export.MarshalBinary()
+	}
+
+	data, err := json.MarshalIndent(export, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+var (
+	proofArgs struct {
+		file string
+
+		node string
+		root string
+
+		unit   string
+		index  uint64
+		end    uint64
+		format string
+		output string
+	}
+
+	proofCmd = &cobra.Command{
+		Use:   "proof",
+		Short: "Generate merkle proof of chunks or segments in a local file, or fetch segment proof from storage node",
+		Run:   generateProof,
+	}
+)
+
+func init() {
+	proofCmd.Flags().StringVar(&proofArgs.file, "file", "", "File name to generate merkle proof")
+	proofCmd.Flags().StringVar(&proofArgs.node, "node", "", "Ionian storage node URL to fetch segment proof instead of local file")
+	proofCmd.Flags().StringVar(&proofArgs.root, "root", "", "Merkle root of file to fetch segment proof from storage node")
+	proofCmd.Flags().StringVar(&proofArgs.unit, "unit", file.ProofUnitSegment, "Leaf node to prove, chunk or segment")
+	proofCmd.Flags().Uint64Var(&proofArgs.index, "index", 0, "Index of chunk or segment to prove, or the start index of range")
+	proofCmd.Flags().Uint64Var(&proofArgs.end, "end", 0, "End index (exclusive) of range to generate a range proof, default to prove a single chunk or segment")
+	proofCmd.Flags().StringVar(&proofArgs.format, "format", proofFormatJSON, "Format of merkle proof, json or binary")
+	proofCmd.Flags().StringVar(&proofArgs.output, "output", "", "File to write merkle proof, default to stdout")
+
+	rootCmd.AddCommand(proofCmd)
+}
+
+func generateProof(*cobra.Command, []string) {
+	if proofArgs.unit != file.ProofUnitChunk && proofArgs.unit != file.ProofUnitSegment {
+		logrus.WithField("unit", proofArgs.unit).Fatal("Invalid unit, chunk or segment expected")
+	}
+
+	if proofArgs.format != proofFormatJSON && proofArgs.format != proofFormatBinary {
+		logrus.WithField("format", proofArgs.format).Fatal("Invalid format, json or binary expected")
+	}
+
+	if countNonEmpty(proofArgs.file, proofArgs.node) != 1 {
+		logrus.Fatal("Either --file or --node should be specified")
+	}
+
+	var export *file.ProofExport
+	var err error
+
+	if proofArgs.node != "" {
+		export, err = fetchSegmentProof()
+	} else {
+		export, err = generateLocalProof()
+	}
+
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to generate merkle proof")
+	}
+
+	data, err := encodeProofExport(export, proofArgs.format)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to encode merkle proof")
+	}
+
+	if err = writeOutput(proofArgs.output, data); err != nil {
+		logrus.WithError(err).Fatal("Failed to write merkle proof")
+	}
+}
+
+func generateLocalProof() (*file.ProofExport, error) {
+	f, err := file.Open(proofArgs.file)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to open file")
+	}
+	defer f.Close()
+
+	root, err := f.MerkleRoot()
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to calculate merkle root")
+	}
+
+	export := file.ProofExport{
+		Root:         root,
+		FileSize:     f.Size(),
+		Unit:         proofArgs.unit,
+		Index:        proofArgs.index,
+		End:          proofArgs.end,
+		NumLeafNodes: file.NumSegmentsFlowPadded(f.Size()),
+	}
+
+	if proofArgs.unit == file.ProofUnitChunk {
+		export.NumLeafNodes = file.NumChunksFlowPadded(f.Size())
+	}
+
+	if proofArgs.end == 0 {
+		var proof merkle.Proof
+		if proofArgs.unit == file.ProofUnitChunk {
+			proof, err = f.ChunkProof(proofArgs.index)
+		} else {
+			proof, err = f.SegmentProof(proofArgs.index)
+		}
+
+		export.Proof = &proof
+	} else {
+		var proof merkle.RangeProof
+		if proofArgs.unit == file.ProofUnitChunk {
+			proof, err = f.ChunkRangeProof(proofArgs.index, proofArgs.end)
+		} else {
+			proof, err = f.SegmentRangeProof(proofArgs.index, proofArgs.end)
+		}
+
+		export.RangeProof = &proof
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &export, nil
+}
+
+// fetchSegmentProof fetches segment proof from storage node, which is validated before export.
+func fetchSegmentProof() (*file.ProofExport, error) {
+	if proofArgs.root == "" {
+		return nil, errors.New("File root required to fetch segment proof from storage node")
+	}
+
+	if proofArgs.unit != file.ProofUnitSegment || proofArgs.end > 0 {
+		return nil, errors.New("Only single segment proof could be fetched from storage node")
+	}
+
+	client := node.MustNewClient(proofArgs.node)
+	defer client.Close()
+
+	ctx := context.Background()
+	root := ethCommon.HexToHash(proofArgs.root)
+
+	info, err := client.Ionian().GetFileInfoContext(ctx, root)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to get file info from storage node")
+	}
+
+	if info == nil {
+		return nil, errors.New("File not found on storage node")
+	}
+
+	segment, err := client.Ionian().DownloadSegmentWithProofContext(ctx, root, proofArgs.index)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to download segment with proof from storage node")
+	}
+
+	if segment == nil {
+		return nil, errors.New("Segment not found on storage node")
+	}
+
+	// data of the last segment is aligned with chunks
+	fileSize := int64(info.Tx.Size)
+	if remaining := fileSize - int64(proofArgs.index*file.DefaultSegmentSize); remaining > 0 && int64(len(segment.Data)) > remaining {
+		segment.Data = segment.Data[:remaining]
+	}
+
+	if err = file.ValidateSegmentProof(segment.Proof, root, segment.Data, proofArgs.index, fileSize); err != nil {
+		return nil, errors.WithMessage(err, "Failed to validate segment proof from storage node")
+	}
+
+	return &file.ProofExport{
+		Root:         root,
+		FileSize:     fileSize,
+		Unit:         file.ProofUnitSegment,
+		Index:        proofArgs.index,
+		NumLeafNodes: file.NumSegmentsFlowPadded(fileSize),
+		Proof:        &segment.Proof,
+	}, nil
+}
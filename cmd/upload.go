@@ -1,6 +1,11 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"runtime"
+	"time"
+
 	"github.com/Ionian-Web3-Storage/ionian-client/common"
 	"github.com/Ionian-Web3-Storage/ionian-client/contract"
 	"github.com/Ionian-Web3-Storage/ionian-client/file"
@@ -13,16 +18,29 @@ import (
 
 var (
 	uploadArgs struct {
-		file string
-		tags string
+		file  string
+		dir   string
+		batch string
+		tags  string
 
 		url      string
 		contract string
 		key      string
 
-		node string
+		nodes []string
+
+		force           bool
+		segmentsPerNode uint
+		maxRetries      int
+		retryInterval   time.Duration
+		partSize        int64
+		hashWorkers     int
+		noCache         bool
+
+		encryption encryptionArgs
 
-		force bool
+		batchConcurrency uint
+		batchReport      string
 	}
 
 	uploadCmd = &cobra.Command{
@@ -33,8 +51,9 @@ var (
 )
 
 func init() {
-	uploadCmd.Flags().StringVar(&uploadArgs.file, "file", "", "File name to upload")
-	uploadCmd.MarkFlagRequired("file")
+	uploadCmd.Flags().StringVar(&uploadArgs.file, "file", "", "File name to upload, or - to read data from stdin")
+	uploadCmd.Flags().StringVar(&uploadArgs.dir, "dir", "", "Folder to upload all files in it along with a manifest")
+	uploadCmd.Flags().StringVar(&uploadArgs.batch, "batch", "", "File that lists files to upload in batch, one file path per line")
 	uploadCmd.Flags().StringVar(&uploadArgs.tags, "tags", "0x", "Tags of the file")
 
 	uploadCmd.Flags().StringVar(&uploadArgs.url, "url", "", "Fullnode URL to interact with Ionian smart contract")
@@ -44,15 +63,30 @@ func init() {
 	uploadCmd.Flags().StringVar(&uploadArgs.key, "key", "", "Private key to interact with smart contract")
 	uploadCmd.MarkFlagRequired("key")
 
-	uploadCmd.Flags().StringVar(&uploadArgs.node, "node", "", "Ionian storage node URL")
+	uploadCmd.Flags().StringSliceVar(&uploadArgs.nodes, "node", []string{}, "Ionian storage node URL. Multiple nodes could be specified and separated by comma, e.g. url1,url2,url3")
 	uploadCmd.MarkFlagRequired("node")
 
 	uploadCmd.Flags().BoolVar(&uploadArgs.force, "force", false, "Force to upload file even already exists")
+	uploadCmd.Flags().UintVar(&uploadArgs.segmentsPerNode, "segments-per-node", 1, "Number of segments to upload in parallel for each storage node")
+	uploadCmd.Flags().IntVar(&uploadArgs.maxRetries, "max-retries", 5, "Max number of retries to upload a segment")
+	uploadCmd.Flags().DurationVar(&uploadArgs.retryInterval, "retry-interval", time.Second, "Backoff interval for the first retry, doubled for each retry")
+
+	uploadCmd.Flags().IntVar(&uploadArgs.hashWorkers, "hash-workers", runtime.NumCPU(), "Number of routines to calculate file merkle root in parallel")
+	uploadCmd.Flags().BoolVar(&uploadArgs.noCache, "no-cache", false, "Force to calculate file merkle root without cached segment roots")
+	uploadCmd.Flags().Int64Var(&uploadArgs.partSize, "part-size", file.DefaultPartSize, "Max size in bytes of each part to split large file, should be multiple of segment size")
+	uploadArgs.encryption.addFlags(uploadCmd, "encrypt", "Whether to encrypt file on client side before upload")
+
+	uploadCmd.Flags().UintVar(&uploadArgs.batchConcurrency, "batch-concurrency", 4, "Number of files to upload in parallel in batch")
+	uploadCmd.Flags().StringVar(&uploadArgs.batchReport, "batch-report", "", "CSV file to write the result of each file in batch, default to stdout")
 
 	rootCmd.AddCommand(uploadCmd)
 }
 
 func upload(*cobra.Command, []string) {
+	if countNonEmpty(uploadArgs.file, uploadArgs.dir, uploadArgs.batch) != 1 {
+		logrus.Fatal("Either --file, --dir or --batch should be specified")
+	}
+
 	client := common.MustNewWeb3(uploadArgs.url, uploadArgs.key)
 	defer client.Close()
 	contractAddr := ethCommon.HexToAddress(uploadArgs.contract)
@@ -61,15 +95,92 @@ func upload(*cobra.Command, []string) {
 		logrus.WithError(err).Fatal("Failed to create flow contract")
 	}
 
-	node := node.MustNewClient(uploadArgs.node)
-	defer node.Close()
+	nodes := node.MustNewClients(uploadArgs.nodes)
+	for _, client := range nodes {
+		defer client.Close()
+	}
 
-	uploader := file.NewUploader(flow, node)
+	uploader := file.NewUploader(flow, nodes...)
 	opt := file.UploadOption{
-		Tags:  hexutil.MustDecode(uploadArgs.tags),
-		Force: uploadArgs.force,
+		Tags:            hexutil.MustDecode(uploadArgs.tags),
+		Force:           uploadArgs.force,
+		SegmentsPerNode: uploadArgs.segmentsPerNode,
+		Retry: file.RetryOption{
+			MaxRetries: uploadArgs.maxRetries,
+			Interval:   uploadArgs.retryInterval,
+		},
+		Progress:    newProgressBar(),
+		Encryption:  uploadArgs.encryption.mustLoadKey(),
+		PartSize:    uploadArgs.partSize,
+		HashWorkers: uploadArgs.hashWorkers,
+		RootCache:   newRootCache(uploadArgs.noCache),
+	}
+	if uploadArgs.batch != "" {
+		// progress bar is not rendered for files uploaded in parallel
+		opt.Progress = nil
+		uploadBatch(uploader, opt)
+		return
 	}
-	if err := uploader.Upload(uploadArgs.file, opt); err != nil {
+
+	if uploadArgs.dir != "" {
+		root, err := uploader.UploadDir(context.Background(), uploadArgs.dir, opt)
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to upload folder")
+		}
+
+		logrus.WithField("root", root).Info("Manifest root of the uploaded folder")
+
+		return
+	}
+
+	if uploadArgs.file == "-" {
+		err = uploader.UploadReader(context.Background(), os.Stdin, opt)
+	} else {
+		err = uploader.Upload(uploadArgs.file, opt)
+	}
+
+	if err != nil {
 		logrus.WithError(err).Fatal("Failed to upload file")
 	}
 }
+
+func uploadBatch(uploader *file.Uploader, opt file.UploadOption) {
+	filenames, err := readLines(uploadArgs.batch)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to read batch file")
+	}
+
+	results, err := uploader.UploadBatch(context.Background(), filenames, uploadArgs.batchConcurrency, opt)
+	if err != nil {
+		logrus.WithError(err).Error("Batch upload terminated")
+	}
+
+	var failed int
+	records := [][]string{{"file", "root", "tx_hash", "status", "error"}}
+
+	for _, v := range results {
+		var txHash string
+		if v.TxHash != nil {
+			txHash = v.TxHash.Hex()
+		}
+
+		if v.Status == file.BatchStatusFailed {
+			failed++
+		}
+
+		records = append(records, []string{v.File, v.Root.Hex(), txHash, v.Status, v.Error})
+	}
+
+	if err = writeCSV(uploadArgs.batchReport, records); err != nil {
+		logrus.WithError(err).Fatal("Failed to write batch report")
+	}
+
+	if failed > 0 {
+		logrus.WithFields(logrus.Fields{
+			"total":  len(results),
+			"failed": failed,
+		}).Fatal("Failed to upload some files in batch")
+	}
+
+	logrus.WithField("total", len(results)).Info("Succeeded to upload files in batch")
+}
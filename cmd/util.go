@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"os"
+	"strings"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/file"
+	"github.com/sirupsen/logrus"
+)
+
+// countNonEmpty returns the number of non-empty values.
+func countNonEmpty(values ...string) int {
+	var count int
+
+	for _, v := range values {
+		if v != "" {
+			count++
+		}
+	}
+
+	return count
+}
+
+// readLines reads non-empty lines from the specified file, and lines that start with # are ignored.
+func readLines(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+// writeCSV writes records to the specified file, or stdout if filename is empty.
+func writeCSV(filename string, records [][]string) error {
+	if filename == "" {
+		return csv.NewWriter(os.Stdout).WriteAll(records)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if err = csv.NewWriter(file).WriteAll(records); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// writeOutput writes data to the specified file, or stdout if filename is empty.
+func writeOutput(filename string, data []byte) error {
+	if filename == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	return os.WriteFile(filename, data, 0644)
+}
+
+// newRootCache creates the default root cache, or returns nil if disabled or failed to create.
+func newRootCache(noCache bool) *file.RootCache {
+	if noCache {
+		return nil
+	}
+
+	cache, err := file.NewDefaultRootCache()
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to create root cache, merkle root will always be calculated")
+		return nil
+	}
+
+	return cache
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/file"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyProofArgs struct {
+		proof    string
+		root     string
+		fileSize int64
+		unit     string
+		index    uint64
+		leaf     string
+		data     string
+	}
+
+	verifyProofCmd = &cobra.Command{
+		Use:   "verify-proof",
+		Short: "Verify merkle proof against file root offline",
+		Run:   verifyProof,
+	}
+)
+
+func init() {
+	verifyProofCmd.Flags().StringVar(&verifyProofArgs.proof, "proof", "", "File of merkle proof in JSON or binary format")
+	verifyProofCmd.MarkFlagRequired("proof")
+	verifyProofCmd.Flags().StringVar(&verifyProofArgs.root, "root", "", "Trusted merkle root of file to verify against")
+	verifyProofCmd.MarkFlagRequired("root")
+	verifyProofCmd.Flags().Int64Var(&verifyProofArgs.fileSize, "file-size", 0, "Trusted file size in bytes to derive the number of leaf nodes")
+	verifyProofCmd.MarkFlagRequired("file-size")
+	verifyProofCmd.Flags().StringVar(&verifyProofArgs.unit, "unit", "", "Trusted leaf node to verify, chunk or segment")
+	verifyProofCmd.MarkFlagRequired("unit")
+	verifyProofCmd.Flags().Uint64Var(&verifyProofArgs.index, "index", 0, "Position of leaf node, or the start position of range")
+	verifyProofCmd.MarkFlagRequired("index")
+	verifyProofCmd.Flags().StringVar(&verifyProofArgs.leaf, "leaf", "", "Hash of leaf node to verify")
+	verifyProofCmd.Flags().StringVar(&verifyProofArgs.data, "data", "", "File of chunk or segment data to verify, which is required for range proof")
+
+	rootCmd.AddCommand(verifyProofCmd)
+}
+
+// verifyProof verifies the proof with trusted parameters only, and the unit, position, number of leaf
+// nodes and leaf hash in proof file are never used, which could be forged along with the proof.
+func verifyProof(*cobra.Command, []string) {
+	unit := verifyProofArgs.unit
+	if unit != file.ProofUnitChunk && unit != file.ProofUnitSegment {
+		logrus.WithField("unit", unit).Fatal("Invalid unit, chunk or segment expected")
+	}
+
+	if verifyProofArgs.fileSize <= 0 {
+		logrus.Fatal("Invalid file size")
+	}
+
+	if (verifyProofArgs.leaf == "") == (verifyProofArgs.data == "") {
+		logrus.Fatal("Either --leaf or --data should be specified")
+	}
+
+	content, err := os.ReadFile(verifyProofArgs.proof)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to read proof file")
+	}
+
+	export, err := file.DecodeProofExport(content)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to decode proof file")
+	}
+
+	// proof file for a different tree shape
+	if export.Unit != unit {
+		logrus.WithField("unit", export.Unit).Fatal("Unit mismatch with proof file")
+	}
+
+	root := ethCommon.HexToHash(verifyProofArgs.root)
+	index := verifyProofArgs.index
+
+	numLeafNodes := file.NumSegmentsFlowPadded(verifyProofArgs.fileSize)
+	if unit == file.ProofUnitChunk {
+		numLeafNodes = file.NumChunksFlowPadded(verifyProofArgs.fileSize)
+	}
+
+	fields := logrus.Fields{
+		"root":         root,
+		"unit":         unit,
+		"index":        index,
+		"numLeafNodes": numLeafNodes,
+	}
+
+	if verifyProofArgs.leaf != "" {
+		if export.Proof == nil {
+			logrus.Fatal("Leaf hash is not supported for range proof")
+		}
+
+		err = export.Proof.ValidateHash(root, ethCommon.HexToHash(verifyProofArgs.leaf), index, numLeafNodes)
+	} else {
+		var data []byte
+		if data, err = os.ReadFile(verifyProofArgs.data); err != nil {
+			logrus.WithError(err).Fatal("Failed to read data file")
+		}
+
+		fields["size"] = len(data)
+		err = validateProofData(export, unit, root, data, index, verifyProofArgs.fileSize)
+	}
+
+	if err != nil {
+		logrus.WithError(err).WithFields(fields).Fatal("Failed to verify merkle proof")
+	}
+
+	logrus.WithFields(fields).Info("Succeeded to verify merkle proof")
+}
+
+// validateProofData validates the proof of chunks or segments in data, which starts from index.
+func validateProofData(export *file.ProofExport, unit string, root ethCommon.Hash, data []byte, index uint64, fileSize int64) error {
+	switch {
+	case export.Proof != nil && unit == file.ProofUnitChunk:
+		return file.ValidateChunkProof(*export.Proof, root, data, index, fileSize)
+	case export.Proof != nil:
+		return file.ValidateSegmentProof(*export.Proof, root, data, index, fileSize)
+	case export.RangeProof != nil && unit == file.ProofUnitChunk:
+		return file.ValidateChunkRangeProof(*export.RangeProof, root, data, index, fileSize)
+	case export.RangeProof != nil:
+		return file.ValidateSegmentRangeProof(*export.RangeProof, root, data, index, fileSize)
+	default:
+		return errors.New("Proof not found in proof file")
+	}
+}
@@ -1,5 +1,7 @@
 package parallel
 
+import "context"
+
 type Result struct {
 	Routine int
 	Task    int
@@ -11,3 +13,19 @@ type Interface interface {
 	ParallelDo(routine, task int) (interface{}, error)
 	ParallelCollect(result *Result) error
 }
+
+// ContextInterface is the context-aware variant of Interface, of which tasks are notified to
+// terminate once the context is done.
+type ContextInterface interface {
+	ParallelDoContext(ctx context.Context, routine, task int) (interface{}, error)
+	ParallelCollect(result *Result) error
+}
+
+// contextAdapter adapts Interface to ContextInterface, which ignores the context to do tasks.
+type contextAdapter struct {
+	Interface
+}
+
+func (adapter contextAdapter) ParallelDoContext(ctx context.Context, routine, task int) (interface{}, error) {
+	return adapter.ParallelDo(routine, task)
+}
@@ -6,6 +6,12 @@ import (
 )
 
 func Serial(parallelizable Interface, tasks, routines, window int) error {
+	return SerialContext(context.Background(), contextAdapter{parallelizable}, tasks, routines, window)
+}
+
+// SerialContext executes tasks in parallel and collects results in sequence. It terminates
+// once any task failed or the specified context is done.
+func SerialContext(ctx context.Context, parallelizable ContextInterface, tasks, routines, window int) error {
 	if tasks == 0 {
 		return nil
 	}
@@ -28,7 +34,7 @@ func Serial(parallelizable Interface, tasks, routines, window int) error {
 	defer close(resultCh)
 
 	var wg sync.WaitGroup
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
 
 	// start routines to do tasks
 	for i := 0; i < routines; i++ {
@@ -36,7 +42,7 @@ func Serial(parallelizable Interface, tasks, routines, window int) error {
 		go work(ctx, i, parallelizable, taskCh, resultCh, &wg)
 	}
 
-	err := collect(parallelizable, taskCh, resultCh, tasks, window)
+	err := collect(ctx, parallelizable, taskCh, resultCh, tasks, window)
 
 	// notify all routines to terminate
 	cancel()
@@ -47,7 +53,7 @@ func Serial(parallelizable Interface, tasks, routines, window int) error {
 	return err
 }
 
-func work(ctx context.Context, routine int, parallelizable Interface, taskCh <-chan int, resultCh chan<- *Result, wg *sync.WaitGroup) {
+func work(ctx context.Context, routine int, parallelizable ContextInterface, taskCh <-chan int, resultCh chan<- *Result, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	for {
@@ -55,7 +61,7 @@ func work(ctx context.Context, routine int, parallelizable Interface, taskCh <-c
 		case <-ctx.Done():
 			return
 		case task := <-taskCh:
-			val, err := parallelizable.ParallelDo(routine, task)
+			val, err := parallelizable.ParallelDoContext(ctx, routine, task)
 			resultCh <- &Result{routine, task, val, err}
 			if err != nil {
 				return
@@ -64,7 +70,7 @@ func work(ctx context.Context, routine int, parallelizable Interface, taskCh <-c
 	}
 }
 
-func collect(parallelizable Interface, taskCh chan<- int, resultCh <-chan *Result, tasks, window int) error {
+func collect(ctx context.Context, parallelizable ContextInterface, taskCh chan<- int, resultCh <-chan *Result, tasks, window int) error {
 	// fill window at first
 	for i := 0; i < window && i < tasks; i++ {
 		taskCh <- i
@@ -73,7 +79,15 @@ func collect(parallelizable Interface, taskCh chan<- int, resultCh <-chan *Resul
 	var next int
 	cache := map[int]*Result{}
 
-	for result := range resultCh {
+	for {
+		var result *Result
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case result = <-resultCh:
+		}
+
 		if result.err != nil {
 			return result.err
 		}
@@ -97,9 +111,7 @@ func collect(parallelizable Interface, taskCh chan<- int, resultCh <-chan *Resul
 		}
 
 		if next >= tasks {
-			break
+			return nil
 		}
 	}
-
-	return nil
 }
@@ -1,6 +1,7 @@
 package parallel
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -38,3 +39,22 @@ func TestSerial(t *testing.T) {
 		assert.Equal(t, i*i, f.result[i])
 	}
 }
+
+type blocked struct{}
+
+func (b *blocked) ParallelDoContext(ctx context.Context, routine, task int) (interface{}, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (b *blocked) ParallelCollect(result *Result) error {
+	return nil
+}
+
+func TestSerialContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := SerialContext(ctx, &blocked{}, 100, 4, 16)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,89 @@
+package parallel
+
+import (
+	"context"
+	"sync"
+)
+
+func Unordered(parallelizable Interface, tasks, routines, window int) error {
+	return UnorderedContext(context.Background(), contextAdapter{parallelizable}, tasks, routines, window)
+}
+
+// UnorderedContext executes tasks in parallel and collects results as soon as available, so that
+// a slow task will not block the others in window. Note, results are collected in a single goroutine.
+// It terminates once any task failed or the specified context is done.
+func UnorderedContext(ctx context.Context, parallelizable ContextInterface, tasks, routines, window int) error {
+	if tasks == 0 {
+		return nil
+	}
+
+	if routines == 0 {
+		routines = 1
+	}
+
+	if routines > tasks {
+		routines = tasks
+	}
+
+	if window < routines {
+		window = routines
+	}
+
+	taskCh := make(chan int, window)
+	defer close(taskCh)
+	resultCh := make(chan *Result, window)
+	defer close(resultCh)
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(ctx)
+
+	// start routines to do tasks
+	for i := 0; i < routines; i++ {
+		wg.Add(1)
+		go work(ctx, i, parallelizable, taskCh, resultCh, &wg)
+	}
+
+	err := collectUnordered(ctx, parallelizable, taskCh, resultCh, tasks, window)
+
+	// notify all routines to terminate
+	cancel()
+
+	// wait for termination for all routines
+	wg.Wait()
+
+	return err
+}
+
+func collectUnordered(ctx context.Context, parallelizable ContextInterface, taskCh chan<- int, resultCh <-chan *Result, tasks, window int) error {
+	// fill window at first
+	next := 0
+	for ; next < window && next < tasks; next++ {
+		taskCh <- next
+	}
+
+	for collected := 0; collected < tasks; collected++ {
+		var result *Result
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case result = <-resultCh:
+		}
+
+		if result.err != nil {
+			return result.err
+		}
+
+		if err := parallelizable.ParallelCollect(result); err != nil {
+			return err
+		}
+
+		// dispatch new task
+		if next < tasks {
+			taskCh <- next
+			next++
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package parallel
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type slowFirst struct {
+	result []int
+}
+
+func (s *slowFirst) ParallelDo(routine, task int) (interface{}, error) {
+	if task == 0 {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return task * task, nil
+}
+
+func (s *slowFirst) ParallelCollect(result *Result) error {
+	s.result = append(s.result, result.Task)
+	return nil
+}
+
+func TestUnordered(t *testing.T) {
+	s := slowFirst{}
+
+	tasks := 100
+
+	err := Unordered(&s, tasks, 4, 16)
+	assert.Nil(t, err)
+	assert.Equal(t, tasks, len(s.result))
+
+	// the slow task should not block others
+	assert.NotEqual(t, 0, s.result[0])
+
+	sort.Ints(s.result)
+	for i := 0; i < tasks; i++ {
+		assert.Equal(t, i, s.result[i])
+	}
+}
+
+func TestUnorderedContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := UnorderedContext(ctx, &blocked{}, 100, 4, 16)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -1,6 +1,7 @@
 package contract
 
 import (
+	"context"
 	"math/big"
 	"time"
 
@@ -46,6 +47,27 @@ func (c *contract) CreateTransactOpts() (*bind.TransactOpts, error) {
 	}, nil
 }
 
+// Account returns the account to send transactions.
+func (c *contract) Account() common.Address {
+	return c.account
+}
+
+// PendingNonce returns the nonce of account in pending state, which is the nonce for next transaction.
+func (c *contract) PendingNonce() (uint64, error) {
+	pending := types.BlockNumberOrHashWithNumber(types.PendingBlockNumber)
+
+	nonce, err := c.client.Eth.TransactionCount(c.account, &pending)
+	if err != nil {
+		return 0, err
+	}
+
+	return nonce.Uint64(), nil
+}
+
 func (c *contract) WaitForReceipt(txHash common.Hash, successRequired bool, pollInterval ...time.Duration) (*types.Receipt, error) {
 	return WaitForReceipt(c.client, txHash, successRequired, pollInterval...)
 }
+
+func (c *contract) WaitForReceiptContext(ctx context.Context, txHash common.Hash, successRequired bool, pollInterval ...time.Duration) (*types.Receipt, error) {
+	return WaitForReceiptContext(ctx, c.client, txHash, successRequired, pollInterval...)
+}
@@ -1,7 +1,9 @@
 package contract
 
 import (
+	"context"
 	"fmt"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/openweb3/web3go"
@@ -29,11 +31,28 @@ func NewFlowExt(flowAddress common.Address, clientWithSigner *web3go.Client) (*F
 }
 
 func (flow *FlowExt) SubmitExt(submission IonianSubmission) (common.Hash, error) {
+	return flow.SubmitExtContext(context.Background(), submission)
+}
+
+func (flow *FlowExt) SubmitExtContext(ctx context.Context, submission IonianSubmission) (common.Hash, error) {
+	return flow.submit(ctx, submission, nil)
+}
+
+// SubmitExtWithNonce sends transaction with the specified nonce, so that multiple transactions
+// could be sent back to back without waiting for receipts, see NonceManager for more details.
+func (flow *FlowExt) SubmitExtWithNonce(ctx context.Context, submission IonianSubmission, nonce uint64) (common.Hash, error) {
+	return flow.submit(ctx, submission, new(big.Int).SetUint64(nonce))
+}
+
+func (flow *FlowExt) submit(ctx context.Context, submission IonianSubmission, nonce *big.Int) (common.Hash, error) {
 	opts, err := flow.CreateTransactOpts()
 	if err != nil {
 		return common.Hash{}, err
 	}
 
+	opts.Context = ctx
+	opts.Nonce = nonce
+
 	tx, err := flow.Submit(opts, submission)
 	if err != nil {
 		return common.Hash{}, err
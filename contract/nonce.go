@@ -0,0 +1,50 @@
+package contract
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// NonceManager allocates nonces in sequence to send transactions back to back, without waiting
+// for the previous transaction to be mined.
+type NonceManager struct {
+	fetch func() (uint64, error)
+
+	mu   sync.Mutex
+	next *uint64
+}
+
+// NewNonceManager creates a nonce manager for the account of contract.
+func (c *contract) NewNonceManager() *NonceManager {
+	return &NonceManager{fetch: c.PendingNonce}
+}
+
+// Next allocates the next nonce, which is fetched from blockchain for the first time.
+func (m *NonceManager) Next() (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.next == nil {
+		nonce, err := m.fetch()
+		if err != nil {
+			return 0, errors.WithMessage(err, "Failed to get pending nonce")
+		}
+
+		m.next = &nonce
+	}
+
+	nonce := *m.next
+	*m.next++
+
+	return nonce, nil
+}
+
+// Reset resets the allocated nonce, e.g. failed to send transaction, so that nonce will be
+// fetched from blockchain again for the next transaction.
+func (m *NonceManager) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.next = nil
+}
@@ -0,0 +1,44 @@
+package contract
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNonceManager(t *testing.T) {
+	pending := uint64(5)
+	fetched := 0
+
+	m := NonceManager{fetch: func() (uint64, error) {
+		fetched++
+		return pending, nil
+	}}
+
+	for i := uint64(0); i < 3; i++ {
+		nonce, err := m.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, 5+i, nonce)
+	}
+
+	assert.Equal(t, 1, fetched)
+
+	// fetch again after reset
+	pending = 7
+	m.Reset()
+
+	nonce, err := m.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), nonce)
+	assert.Equal(t, 2, fetched)
+}
+
+func TestNonceManagerFetchError(t *testing.T) {
+	m := NonceManager{fetch: func() (uint64, error) {
+		return 0, errors.New("network error")
+	}}
+
+	_, err := m.Next()
+	assert.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package contract
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"math/big"
@@ -18,13 +19,22 @@ import (
 )
 
 func WaitForReceipt(client *web3go.Client, txHash common.Hash, successRequired bool, pollInterval ...time.Duration) (receipt *types.Receipt, err error) {
+	return WaitForReceiptContext(context.Background(), client, txHash, successRequired, pollInterval...)
+}
+
+// WaitForReceiptContext polls the transaction receipt until available or the specified context is done.
+func WaitForReceiptContext(ctx context.Context, client *web3go.Client, txHash common.Hash, successRequired bool, pollInterval ...time.Duration) (receipt *types.Receipt, err error) {
 	interval := time.Second
 	if len(pollInterval) > 0 && pollInterval[0] > 0 {
 		interval = pollInterval[0]
 	}
 
 	for receipt == nil {
-		time.Sleep(interval)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
 
 		if receipt, err = client.Eth.TransactionReceipt(txHash); err != nil {
 			return nil, err
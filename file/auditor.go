@@ -0,0 +1,290 @@
+package file
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/node"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultAuditSamples = 10
+	defaultAuditTimeout = 30 * time.Second
+)
+
+// AuditOption configures how to audit file availability on storage nodes. Any zero field
+// falls back to the default value.
+type AuditOption struct {
+	Samples int           // number of random segments to sample on each storage node, default 10
+	Timeout time.Duration // timeout to download a sampled segment, default 30s
+	Size    int64         // trusted file size, default to the size agreed by majority of storage nodes
+}
+
+func (opt AuditOption) withDefaults() AuditOption {
+	if opt.Samples <= 0 {
+		opt.Samples = defaultAuditSamples
+	}
+
+	if opt.Timeout <= 0 {
+		opt.Timeout = defaultAuditTimeout
+	}
+
+	return opt
+}
+
+// SegmentAudit is the result to sample a segment with merkle proof on a storage node.
+type SegmentAudit struct {
+	Segment uint64
+	Latency time.Duration
+	Error   error // nil if segment downloaded and proof validated
+}
+
+// NodeAudit is the audit result of file on a storage node.
+type NodeAudit struct {
+	Node    string
+	Info    *node.FileInfo // nil if file not found on storage node
+	Samples []SegmentAudit
+	Error   error // failed to audit, e.g. file not found or not finalized
+}
+
+// Available returns the number of sampled segments that proved available.
+func (audit *NodeAudit) Available() int {
+	var available int
+
+	for _, sample := range audit.Samples {
+		if sample.Error == nil {
+			available++
+		}
+	}
+
+	return available
+}
+
+// Availability returns the ratio of sampled segments that proved available, which is 0 if not sampled.
+func (audit *NodeAudit) Availability() float64 {
+	if len(audit.Samples) == 0 {
+		return 0
+	}
+
+	return float64(audit.Available()) / float64(len(audit.Samples))
+}
+
+// AvgLatency returns the average latency of sampled segments that proved available.
+func (audit *NodeAudit) AvgLatency() time.Duration {
+	var total time.Duration
+
+	for _, sample := range audit.Samples {
+		if sample.Error == nil {
+			total += sample.Latency
+		}
+	}
+
+	if available := audit.Available(); available > 0 {
+		return total / time.Duration(available)
+	}
+
+	return 0
+}
+
+// MaxLatency returns the max latency of sampled segments that proved available.
+func (audit *NodeAudit) MaxLatency() time.Duration {
+	var max time.Duration
+
+	for _, sample := range audit.Samples {
+		if sample.Error == nil && sample.Latency > max {
+			max = sample.Latency
+		}
+	}
+
+	return max
+}
+
+// Auditor checks whether a file could be retrieved from storage nodes, by sampling random
+// segments with merkle proof instead of downloading the whole file.
+type Auditor struct {
+	clients []*node.Client
+	option  AuditOption
+}
+
+func NewAuditor(clients ...*node.Client) *Auditor {
+	if len(clients) == 0 {
+		panic("storage node not specified")
+	}
+
+	return &Auditor{
+		clients: clients,
+		option:  AuditOption{}.withDefaults(),
+	}
+}
+
+// WithOption sets the option to audit file.
+func (auditor *Auditor) WithOption(option AuditOption) *Auditor {
+	auditor.option = option.withDefaults()
+	return auditor
+}
+
+// Audit audits the file of specified root on all storage nodes in parallel, and returns the audit
+// result of each storage node in order. Error returned only if file not found on any storage node,
+// or file size could not be determined.
+//
+// The file size is used to validate merkle proofs of sampled segments on all storage nodes, which is
+// AuditOption.Size if specified. Otherwise, it requires the majority of storage nodes that have the file
+// finalized to agree on the file size, so that a single storage node could not make others fail.
+func (auditor *Auditor) Audit(ctx context.Context, root common.Hash) ([]*NodeAudit, error) {
+	audits := make([]*NodeAudit, len(auditor.clients))
+
+	// query file info from all storage nodes
+	var wg sync.WaitGroup
+	for i, client := range auditor.clients {
+		wg.Add(1)
+
+		go func(i int, client *node.Client) {
+			defer wg.Done()
+
+			audits[i] = &NodeAudit{Node: client.URL()}
+			audits[i].Info, audits[i].Error = client.Ionian().GetFileInfoContext(ctx, root)
+		}(i, client)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var finalized []*NodeAudit
+	for _, audit := range audits {
+		switch {
+		case audit.Error != nil:
+			audit.Error = errors.WithMessage(audit.Error, "Failed to get file info")
+		case audit.Info == nil:
+			audit.Error = errors.New("File not found")
+		case !audit.Info.Finalized:
+			audit.Error = errors.New("File not finalized")
+		default:
+			finalized = append(finalized, audit)
+		}
+	}
+
+	if len(finalized) == 0 {
+		return audits, errors.New("File not available on any storage node")
+	}
+
+	fileSize, err := auditor.fileSize(finalized)
+	if err != nil {
+		for _, audit := range finalized {
+			audit.Error = err
+		}
+
+		return audits, err
+	}
+
+	for _, audit := range finalized {
+		if size := int64(audit.Info.Tx.Size); size != fileSize {
+			audit.Error = errors.Errorf("File size mismatch, expected = %v, actual = %v", fileSize, size)
+		}
+	}
+
+	// sample segments on storage nodes in parallel
+	for i, audit := range audits {
+		if audit.Error != nil {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(client *node.Client, audit *NodeAudit) {
+			defer wg.Done()
+			audit.Samples = auditor.sample(ctx, client, root, fileSize)
+		}(auditor.clients[i], audit)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return audits, nil
+}
+
+// fileSize returns the trusted file size if specified, or the size agreed by majority of storage nodes
+// that have the file finalized.
+func (auditor *Auditor) fileSize(finalized []*NodeAudit) (int64, error) {
+	if auditor.option.Size > 0 {
+		return auditor.option.Size, nil
+	}
+
+	votes := make(map[int64]int)
+	for _, audit := range finalized {
+		size := int64(audit.Info.Tx.Size)
+
+		if votes[size]++; votes[size]*2 > len(finalized) {
+			return size, nil
+		}
+	}
+
+	return 0, errors.Errorf("File size not agreed by majority of %v storage nodes, please specify the trusted file size", len(finalized))
+}
+
+// sample downloads random segments with proof from the storage node one by one, so as to measure latency.
+func (auditor *Auditor) sample(ctx context.Context, client *node.Client, root common.Hash, fileSize int64) []SegmentAudit {
+	numChunks := numSplits(fileSize, DefaultChunkSize)
+	numSegments := numSplits(fileSize, DefaultSegmentSize)
+
+	// sample segments without replacement
+	samples := auditor.option.Samples
+	if uint64(samples) > numSegments {
+		samples = int(numSegments)
+	}
+
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	picked := make(map[uint64]bool)
+
+	var audits []SegmentAudit
+
+	for len(audits) < samples {
+		segmentIndex := uint64(random.Int63n(int64(numSegments)))
+		if picked[segmentIndex] {
+			continue
+		}
+
+		picked[segmentIndex] = true
+
+		startIndex := segmentIndex * DefaultSegmentMaxChunks
+		endIndex := startIndex + DefaultSegmentMaxChunks
+		if endIndex > numChunks {
+			endIndex = numChunks
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, auditor.option.Timeout)
+		start := time.Now()
+		_, _, err := downloadSegmentWithProof(reqCtx, client, root, numChunks, startIndex, endIndex)
+		latency := time.Since(start)
+		cancel()
+
+		entry := logrus.WithFields(logrus.Fields{
+			"node":    client.URL(),
+			"segment": segmentIndex,
+			"latency": latency,
+		})
+
+		if err != nil {
+			entry.WithError(err).Debug("Failed to sample segment to audit")
+		} else {
+			entry.Debug("Succeeded to sample segment to audit")
+		}
+
+		audits = append(audits, SegmentAudit{segmentIndex, latency, err})
+
+		// terminate once the context is done
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return audits
+}
@@ -0,0 +1,86 @@
+package file
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudit(t *testing.T) {
+	data := createTestData(5*DefaultSegmentSize + 100)
+
+	good, client1 := newMockNode(t, data)
+	unavailable, client2 := newMockNode(t, data)
+	corrupted, client3 := newMockNode(t, data)
+	_, client4 := newMockNode(t, createTestData(100))
+	unavailable.unavailable = true
+	corrupted.badSegments = map[uint64]bool{2: true}
+
+	auditor := NewAuditor(client1, client2, client3, client4).WithOption(AuditOption{
+		Samples: 100,
+		Timeout: time.Second,
+	})
+
+	audits, err := auditor.Audit(context.Background(), good.tree.Root())
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(audits))
+
+	// all segments sampled
+	assert.NoError(t, audits[0].Error)
+	assert.Equal(t, 6, len(audits[0].Samples))
+	assert.Equal(t, 6, audits[0].Available())
+	assert.Equal(t, 1.0, audits[0].Availability())
+	assert.True(t, audits[0].MaxLatency() >= audits[0].AvgLatency())
+
+	assert.NoError(t, audits[1].Error)
+	assert.Equal(t, 6, len(audits[1].Samples))
+	assert.Equal(t, 0, audits[1].Available())
+
+	assert.NoError(t, audits[2].Error)
+	assert.Equal(t, 5, audits[2].Available())
+	for _, sample := range audits[2].Samples {
+		assert.Equal(t, sample.Segment == 2, sample.Error != nil)
+	}
+
+	// file not found
+	assert.Error(t, audits[3].Error)
+	assert.Empty(t, audits[3].Samples)
+	assert.Equal(t, 0.0, audits[3].Availability())
+
+	// file not found on any storage node
+	_, err = NewAuditor(client4).Audit(context.Background(), good.tree.Root())
+	assert.Error(t, err)
+}
+
+func TestAuditFileSize(t *testing.T) {
+	data := createTestData(3*DefaultSegmentSize + 100)
+
+	liar, client1 := newMockNode(t, data)
+	good, client2 := newMockNode(t, data)
+	_, client3 := newMockNode(t, data)
+	liar.info.Tx.Size = uint64(len(data) + DefaultSegmentSize)
+
+	option := AuditOption{Samples: 100, Timeout: time.Second}
+
+	// size agreed by majority
+	audits, err := NewAuditor(client1, client2, client3).WithOption(option).Audit(context.Background(), good.tree.Root())
+	assert.NoError(t, err)
+	assert.Error(t, audits[0].Error)
+	assert.Equal(t, 4, audits[1].Available())
+	assert.Equal(t, 4, audits[2].Available())
+
+	// no majority
+	audits, err = NewAuditor(client1, client2).WithOption(option).Audit(context.Background(), good.tree.Root())
+	assert.Error(t, err)
+	assert.Error(t, audits[0].Error)
+	assert.Error(t, audits[1].Error)
+
+	// trusted size
+	option.Size = int64(len(data))
+	audits, err = NewAuditor(client1, client2).WithOption(option).Audit(context.Background(), good.tree.Root())
+	assert.NoError(t, err)
+	assert.Error(t, audits[0].Error)
+	assert.Equal(t, 4, audits[1].Available())
+}
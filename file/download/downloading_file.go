@@ -12,7 +12,7 @@ const downloadingFileSuffix = ".download"
 type DownloadingFile struct {
 	filename   string
 	underlying *os.File
-	metadata   *Metadata
+	metadata   *SparseMetadata
 }
 
 func CreateDownloadingFile(filename string, root common.Hash, size int64) (*DownloadingFile, error) {
@@ -26,14 +26,14 @@ func CreateDownloadingFile(filename string, root common.Hash, size int64) (*Down
 		return nil, errors.WithMessage(err, "Failed to stat file")
 	}
 
-	var metadata *Metadata
+	var metadata *SparseMetadata
 
 	if info.Size() == 0 {
-		metadata = NewMetadata(root, size)
+		metadata = NewSparseMetadata(root, size)
 		if err = metadata.Extend(file); err != nil {
 			return nil, errors.WithMessage(err, "Failed to extend metadata")
 		}
-	} else if metadata, err = LoadMetadata(file); err != nil {
+	} else if metadata, err = loadMetadata(file); err != nil {
 		return nil, errors.WithMessage(err, "Failed to load metadata")
 	}
 
@@ -48,21 +48,74 @@ func CreateDownloadingFile(filename string, root common.Hash, size int64) (*Down
 	return &DownloadingFile{filename, file, metadata}, nil
 }
 
-func (file *DownloadingFile) Metadata() *Metadata {
+// loadMetadata loads metadata from the downloading file, and migrates the legacy metadata
+// if the downloading file was created by an older version.
+func loadMetadata(file *os.File) (*SparseMetadata, error) {
+	sparse, err := IsSparseMetadata(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if sparse {
+		return LoadSparseMetadata(file)
+	}
+
+	legacy, err := LoadMetadata(file)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to load legacy metadata")
+	}
+
+	// remove the legacy metadata and extend with the new one
+	if err = file.Truncate(legacy.Size); err != nil {
+		return nil, errors.WithMessage(err, "Failed to truncate legacy metadata")
+	}
+
+	metadata := newSparseMetadataFromLegacy(legacy)
+	if err = metadata.Extend(file); err != nil {
+		return nil, errors.WithMessage(err, "Failed to extend metadata")
+	}
+
+	return metadata, nil
+}
+
+func (file *DownloadingFile) Metadata() *SparseMetadata {
 	return file.metadata
 }
 
-func (file *DownloadingFile) Write(data []byte) error {
+// WriteSegment writes the specified segment at the final offset, which could be in any order.
+func (file *DownloadingFile) WriteSegment(index uint64, data []byte) error {
+	if file.underlying == nil {
+		return errors.New("File already sealed")
+	}
+
+	return file.metadata.WriteSegment(file.underlying, index, data)
+}
+
+// ReadSegment reads data of the specified segment, which should have been written.
+func (file *DownloadingFile) ReadSegment(index uint64) ([]byte, error) {
+	if file.underlying == nil {
+		return nil, errors.New("File already sealed")
+	}
+
+	if !file.metadata.IsSegmentCompleted(index) {
+		return nil, errors.Errorf("Segment %v not downloaded yet", index)
+	}
+
+	return file.metadata.ReadSegment(file.underlying, index)
+}
+
+// ResetSegment marks the specified segment not downloaded, so that it will be downloaded again on resume.
+func (file *DownloadingFile) ResetSegment(index uint64) error {
 	if file.underlying == nil {
 		return errors.New("File already sealed")
 	}
 
-	return file.metadata.Write(file.underlying, data)
+	return file.metadata.ResetSegment(file.underlying, index)
 }
 
 func (file *DownloadingFile) Seal() error {
-	if file.metadata.Offset < file.metadata.Size {
-		return errors.Errorf("Download incompleted, offset = %v, size = %v", file.metadata.Offset, file.metadata.Size)
+	if !file.metadata.IsCompleted() {
+		return errors.Errorf("Download incompleted, segments = %v, completed = %v", file.metadata.NumSegments, file.metadata.NumCompleted())
 	}
 
 	if err := file.underlying.Truncate(file.metadata.Size); err != nil {
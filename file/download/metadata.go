@@ -10,6 +10,8 @@ import (
 
 const MetadataSize = common.HashLength + 8 + 8
 
+// Metadata is the legacy trailer of downloading file that tracks a linear offset only. It is
+// superseded by SparseMetadata, and kept to resume downloading files created by older versions.
 type Metadata struct {
 	Root   common.Hash // file merkle root
 	Size   int64       // file size to download
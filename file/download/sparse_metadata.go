@@ -0,0 +1,300 @@
+package download
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// segmentSize is the size of segment to download, which is the same as file.DefaultSegmentSize.
+const segmentSize = 256 * 1024
+
+// sparseMetadataMagic is at the end of downloading file to distinguish from the legacy Metadata,
+// whose last 8 bytes is the offset that never be such a large value.
+var sparseMetadataMagic = []byte("IONDL\x00\x00\x02")
+
+// SparseMetadataFixedSize is the size of fixed fields at the end of SparseMetadata.
+const SparseMetadataFixedSize = common.HashLength + 8 + 8
+
+// SparseMetadata is appended at the end of downloading file, which tracks the completed segments
+// in a bitmap, so that segments could be written at the final offsets in any order.
+//
+// Format: [bitmap][root 32 bytes][size 8 bytes][magic 8 bytes]
+type SparseMetadata struct {
+	Root        common.Hash // file merkle root
+	Size        int64       // file size to download
+	NumSegments uint64      // number of segments to download
+
+	bitmap    []byte // completed segments
+	completed uint64 // number of completed segments
+}
+
+func NewSparseMetadata(root common.Hash, size int64) *SparseMetadata {
+	numSegments := uint64((size-1)/segmentSize + 1)
+
+	return &SparseMetadata{
+		Root:        root,
+		Size:        size,
+		NumSegments: numSegments,
+		bitmap:      make([]byte, (numSegments+7)/8),
+	}
+}
+
+// newSparseMetadataFromLegacy converts the legacy metadata, and segments before offset are completed.
+func newSparseMetadataFromLegacy(legacy *Metadata) *SparseMetadata {
+	md := NewSparseMetadata(legacy.Root, legacy.Size)
+
+	completed := uint64(legacy.Offset / segmentSize)
+	if legacy.Offset == legacy.Size {
+		completed = md.NumSegments
+	}
+
+	for i := uint64(0); i < completed; i++ {
+		md.setCompleted(i)
+	}
+
+	return md
+}
+
+// TrailerSize returns the size of serialized metadata at the end of downloading file.
+func (md *SparseMetadata) TrailerSize() int64 {
+	return int64(len(md.bitmap)) + SparseMetadataFixedSize
+}
+
+// IsSparseMetadata returns whether the downloading file ends with SparseMetadata.
+func IsSparseMetadata(file *os.File) (bool, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return false, errors.WithMessage(err, "Failed to stat file")
+	}
+
+	if info.Size() < SparseMetadataFixedSize {
+		return false, nil
+	}
+
+	magic := make([]byte, len(sparseMetadataMagic))
+	if _, err = file.ReadAt(magic, info.Size()-int64(len(magic))); err != nil {
+		return false, errors.WithMessage(err, "Failed to read magic from file")
+	}
+
+	return bytes.Equal(magic, sparseMetadataMagic), nil
+}
+
+func LoadSparseMetadata(file *os.File) (*SparseMetadata, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to stat file")
+	}
+
+	fileSize := info.Size()
+	if fileSize < SparseMetadataFixedSize {
+		return nil, errors.Errorf("File size too small %v", fileSize)
+	}
+
+	// read fixed fields at first to determine the bitmap size
+	fixed := make([]byte, SparseMetadataFixedSize)
+	if _, err = file.ReadAt(fixed, fileSize-SparseMetadataFixedSize); err != nil {
+		return nil, errors.WithMessage(err, "Failed to read metadata from file")
+	}
+
+	size := int64(binary.BigEndian.Uint64(fixed[common.HashLength : common.HashLength+8]))
+	if size <= 0 {
+		return nil, errors.Errorf("Invalid file size in metadata %v", size)
+	}
+
+	trailerSize := NewSparseMetadata(common.Hash{}, size).TrailerSize()
+	if fileSize != size+trailerSize {
+		return nil, errors.Errorf("File size mismatch with metadata, expected = %v, actual = %v", size+trailerSize, fileSize)
+	}
+
+	encoded := make([]byte, trailerSize)
+	if _, err = file.ReadAt(encoded, size); err != nil {
+		return nil, errors.WithMessage(err, "Failed to read metadata from file")
+	}
+
+	md, err := DeserializeSparseMetadata(encoded)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to deserialize metadata")
+	}
+
+	return md, nil
+}
+
+func (md *SparseMetadata) Serialize() []byte {
+	encoded := make([]byte, 0, md.TrailerSize())
+
+	encoded = append(encoded, md.bitmap...)
+	encoded = append(encoded, md.Root.Bytes()...)
+
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(md.Size))
+	encoded = append(encoded, size...)
+
+	return append(encoded, sparseMetadataMagic...)
+}
+
+func DeserializeSparseMetadata(encoded []byte) (*SparseMetadata, error) {
+	if len(encoded) < SparseMetadataFixedSize {
+		return nil, errors.Errorf("Invalid data length %v", len(encoded))
+	}
+
+	fixed := encoded[len(encoded)-SparseMetadataFixedSize:]
+	if !bytes.Equal(fixed[common.HashLength+8:], sparseMetadataMagic) {
+		return nil, errors.New("Invalid metadata magic")
+	}
+
+	size := int64(binary.BigEndian.Uint64(fixed[common.HashLength : common.HashLength+8]))
+	if size <= 0 {
+		return nil, errors.Errorf("Invalid file size %v", size)
+	}
+
+	md := NewSparseMetadata(common.BytesToHash(fixed[:common.HashLength]), size)
+	if int64(len(encoded)) != md.TrailerSize() {
+		return nil, errors.Errorf("Invalid data length, expected = %v, actual = %v", md.TrailerSize(), len(encoded))
+	}
+
+	for i := uint64(0); i < md.NumSegments; i++ {
+		if encoded[i/8]&(1<<(i%8)) != 0 {
+			md.setCompleted(i)
+		}
+	}
+
+	return md, nil
+}
+
+// Extend extends the file with metadata at the end.
+func (md *SparseMetadata) Extend(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return errors.WithMessage(err, "Failed to stat file")
+	}
+
+	// file already truncated and length mismatch with metadata
+	if size := info.Size(); size > 0 && size != md.Size {
+		return errors.Errorf("Invalid file size, expected = %v, actual = %v", md.Size, size)
+	}
+
+	// extend file with metadata
+	if err = file.Truncate(md.Size + md.TrailerSize()); err != nil {
+		return errors.WithMessage(err, "Failed to truncate file to extend metadata")
+	}
+
+	// write metadata at the end of file
+	if _, err = file.WriteAt(md.Serialize(), md.Size); err != nil {
+		return errors.WithMessage(err, "Failed to write metadata")
+	}
+
+	return nil
+}
+
+// WriteSegment writes segment data at the final offset of file, and marks the segment completed.
+// Note, the last segment should be trimmed to the file size.
+func (md *SparseMetadata) WriteSegment(file *os.File, index uint64, data []byte) error {
+	if index >= md.NumSegments {
+		return errors.Errorf("Segment index out of bound, index = %v, segments = %v", index, md.NumSegments)
+	}
+
+	offset := int64(index) * segmentSize
+
+	expectedLen := md.Size - offset
+	if expectedLen > segmentSize {
+		expectedLen = segmentSize
+	}
+
+	if int64(len(data)) != expectedLen {
+		return errors.Errorf("Segment length mismatch, expected = %v, actual = %v", expectedLen, len(data))
+	}
+
+	// write data
+	if _, err := file.WriteAt(data, offset); err != nil {
+		return errors.WithMessage(err, "Failed to write data")
+	}
+
+	// update bitmap of metadata
+	md.setCompleted(index)
+
+	if _, err := file.WriteAt(md.bitmap[index/8:index/8+1], md.Size+int64(index/8)); err != nil {
+		return errors.WithMessage(err, "Failed to update bitmap of metadata")
+	}
+
+	return nil
+}
+
+// ResetSegment marks the specified segment not downloaded, e.g. segment data is corrupted.
+func (md *SparseMetadata) ResetSegment(file *os.File, index uint64) error {
+	if index >= md.NumSegments {
+		return errors.Errorf("Segment index out of bound, index = %v, segments = %v", index, md.NumSegments)
+	}
+
+	if !md.IsSegmentCompleted(index) {
+		return nil
+	}
+
+	md.bitmap[index/8] &^= 1 << (index % 8)
+	md.completed--
+
+	if _, err := file.WriteAt(md.bitmap[index/8:index/8+1], md.Size+int64(index/8)); err != nil {
+		return errors.WithMessage(err, "Failed to update bitmap of metadata")
+	}
+
+	return nil
+}
+
+// ReadSegment reads data of the specified segment from file, and the last segment is trimmed to the file size.
+func (md *SparseMetadata) ReadSegment(file *os.File, index uint64) ([]byte, error) {
+	if index >= md.NumSegments {
+		return nil, errors.Errorf("Segment index out of bound, index = %v, segments = %v", index, md.NumSegments)
+	}
+
+	offset := int64(index) * segmentSize
+
+	length := md.Size - offset
+	if length > segmentSize {
+		length = segmentSize
+	}
+
+	data := make([]byte, length)
+	if _, err := file.ReadAt(data, offset); err != nil {
+		return nil, errors.WithMessage(err, "Failed to read data")
+	}
+
+	return data, nil
+}
+
+func (md *SparseMetadata) setCompleted(index uint64) {
+	if !md.IsSegmentCompleted(index) {
+		md.bitmap[index/8] |= 1 << (index % 8)
+		md.completed++
+	}
+}
+
+// IsSegmentCompleted returns whether the specified segment has been downloaded.
+func (md *SparseMetadata) IsSegmentCompleted(index uint64) bool {
+	return md.bitmap[index/8]&(1<<(index%8)) != 0
+}
+
+// NumCompleted returns the number of completed segments.
+func (md *SparseMetadata) NumCompleted() uint64 {
+	return md.completed
+}
+
+// IsCompleted returns whether all segments have been downloaded.
+func (md *SparseMetadata) IsCompleted() bool {
+	return md.completed == md.NumSegments
+}
+
+// MissingSegments returns indices of segments that not downloaded yet in order.
+func (md *SparseMetadata) MissingSegments() []uint64 {
+	missing := make([]uint64, 0, md.NumSegments-md.completed)
+
+	for i := uint64(0); i < md.NumSegments; i++ {
+		if !md.IsSegmentCompleted(i) {
+			missing = append(missing, i)
+		}
+	}
+
+	return missing
+}
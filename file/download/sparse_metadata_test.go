@@ -0,0 +1,89 @@
+package download
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSparseMetadataSerde(t *testing.T) {
+	md := NewSparseMetadata(testHash, 3*segmentSize+100)
+	md.setCompleted(1)
+	md.setCompleted(3)
+
+	encoded := md.Serialize()
+	assert.Equal(t, md.TrailerSize(), int64(len(encoded)))
+
+	md2, err := DeserializeSparseMetadata(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, md, md2)
+	assert.Equal(t, []uint64{0, 2}, md2.MissingSegments())
+}
+
+func TestSparseMetadataWriteSegment(t *testing.T) {
+	tmpFile, err := os.CreateTemp(os.TempDir(), "ionian-client-test-*")
+	assert.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	md := NewSparseMetadata(testHash, 2*segmentSize+100)
+	assert.NoError(t, md.Extend(tmpFile))
+
+	// write out of order
+	assert.NoError(t, md.WriteSegment(tmpFile, 2, make([]byte, 100)))
+	assert.Error(t, md.WriteSegment(tmpFile, 0, make([]byte, 100)))
+	assert.NoError(t, md.WriteSegment(tmpFile, 0, make([]byte, segmentSize)))
+	assert.False(t, md.IsCompleted())
+
+	sparse, err := IsSparseMetadata(tmpFile)
+	assert.NoError(t, err)
+	assert.True(t, sparse)
+
+	md2, err := LoadSparseMetadata(tmpFile)
+	assert.NoError(t, err)
+	assert.Equal(t, md, md2)
+	assert.Equal(t, []uint64{1}, md2.MissingSegments())
+
+	assert.NoError(t, md2.WriteSegment(tmpFile, 1, make([]byte, segmentSize)))
+	assert.True(t, md2.IsCompleted())
+}
+
+func TestDownloadingFileLegacyMetadata(t *testing.T) {
+	tmpFile, err := os.CreateTemp(os.TempDir(), "ionian-client-test-*")
+	assert.NoError(t, err)
+	filename := tmpFile.Name()
+	defer os.Remove(filename)
+	defer os.Remove(filename + downloadingFileSuffix)
+	tmpFile.Close()
+
+	// downloading file created by older version with 1 segment downloaded
+	size := int64(3*segmentSize + 100)
+	legacyFile, err := os.Create(filename + downloadingFileSuffix)
+	assert.NoError(t, err)
+	legacy := NewMetadata(testHash, size)
+	assert.NoError(t, legacy.Extend(legacyFile))
+	assert.NoError(t, legacy.Write(legacyFile, make([]byte, segmentSize)))
+	assert.NoError(t, legacyFile.Close())
+
+	file, err := CreateDownloadingFile(filename, testHash, size)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3}, file.Metadata().MissingSegments())
+
+	for _, index := range []uint64{3, 1, 2} {
+		assert.Error(t, file.Seal())
+
+		data := make([]byte, segmentSize)
+		if index == 3 {
+			data = data[:100]
+		}
+
+		assert.NoError(t, file.WriteSegment(index, data))
+	}
+
+	assert.NoError(t, file.Seal())
+
+	info, err := os.Stat(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, size, info.Size())
+}
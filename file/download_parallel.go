@@ -1,6 +1,7 @@
 package file
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/Ionian-Web3-Storage/ionian-client/common/parallel"
@@ -14,51 +15,160 @@ import (
 const minBufSize = 8
 
 type SegmentDownloader struct {
-	clients []*node.Client
-	file    *download.DownloadingFile
+	pool *nodePool
+	file *download.DownloadingFile
 
 	withProof bool
+	progress  *progressReporter
 
-	segmentOffset uint64
-	numChunks     uint64
-	numSegments   uint64
+	missingSegments []uint64 // segments to download
+	numChunks       uint64
+	numSegments     uint64
+
+	verifier *segmentVerifier
+
+	perNode   int // number of in-flight requests per storage node
+	bandwidth *bandwidthLimiter
+
+	ctx context.Context // context of download in progress, to download bad segment again once collected
 }
 
-func NewSegmentDownloader(clients []*node.Client, file *download.DownloadingFile, withProof bool) (*SegmentDownloader, error) {
-	offset := file.Metadata().Offset
-	if offset%DefaultSegmentSize > 0 {
-		return nil, errors.Errorf("Invalid data offset in downloading file %v", offset)
-	}
+// downloadedSegment is the result of a downloaded segment.
+type downloadedSegment struct {
+	data    []byte
+	root    common.Hash // segment root in flow padded merkle tree
+	nodeUrl string      // storage node that served the segment
+}
 
+// NewSegmentDownloader creates a downloader to download segments from storage nodes in parallel.
+// Failed segment will be retried on other storage nodes, see WithRetry for more details.
+//
+// Only the segments not completed in downloading file will be downloaded. Segment roots are calculated
+// once downloaded to verify against the file root, so that the downloaded file need not to be read again.
+// Besides, segments are verified in blocks once all segments of block downloaded, and a bad segment is
+// downloaded again with merkle proof from other storage nodes in time.
+func NewSegmentDownloader(clients []*node.Client, file *download.DownloadingFile, withProof bool) (*SegmentDownloader, error) {
 	fileSize := file.Metadata().Size
 
 	return &SegmentDownloader{
-		clients: clients,
-		file:    file,
+		pool: newFailoverNodePool(clients, RetryOption{}),
+		file: file,
 
 		withProof: withProof,
 
-		segmentOffset: uint64(offset / DefaultSegmentSize),
-		numChunks:     numSplits(fileSize, DefaultChunkSize),
-		numSegments:   numSplits(fileSize, DefaultSegmentSize),
+		missingSegments: file.Metadata().MissingSegments(),
+		numChunks:       numSplits(fileSize, DefaultChunkSize),
+		numSegments:     numSplits(fileSize, DefaultSegmentSize),
+
+		verifier: newSegmentVerifier(file.Metadata().Root, fileSize),
 	}, nil
 }
 
+// WithRetry sets the retry policy to download segments.
+func (downloader *SegmentDownloader) WithRetry(option RetryOption) *SegmentDownloader {
+	downloader.pool.option = option.withDefaults()
+	return downloader
+}
+
+// WithConcurrency sets the max number of in-flight requests per storage node, default 1. In adaptive
+// mode, in-flight requests of each storage node start from 1, and are increased additively once succeeded,
+// or decreased multiplicatively in case of errors or latency increased significantly.
+func (downloader *SegmentDownloader) WithConcurrency(perNode int, adaptive bool) *SegmentDownloader {
+	downloader.perNode = perNode
+
+	if perNode > 1 || adaptive {
+		downloader.pool.withConcurrency(perNode, adaptive)
+	}
+
+	return downloader
+}
+
+// WithBandwidth limits the download bandwidth in bytes per second, and 0 for unlimited.
+func (downloader *SegmentDownloader) WithBandwidth(bytesPerSecond int64) *SegmentDownloader {
+	downloader.bandwidth = newBandwidthLimiter(bytesPerSecond)
+	return downloader
+}
+
 // Download downloads segments in parallel.
 func (downloader *SegmentDownloader) Download() error {
-	numTasks := downloader.numSegments - downloader.segmentOffset
-	numNodes := len(downloader.clients)
-	bufSize := numNodes * 2
+	return downloader.DownloadContext(context.Background())
+}
+
+// DownloadContext downloads segments in parallel, and terminates once the specified context is done.
+//
+// Segments are written into downloading file as soon as downloaded, so that a slow segment
+// will not block the others. Once all segments of a block downloaded, the block is verified, and
+// the bad segment will be downloaded again from other storage nodes. Finally, the file root is
+// verified, and the first bad segment along with the storage node that served it will be reported
+// if mismatch.
+func (downloader *SegmentDownloader) DownloadContext(ctx context.Context) error {
+	// collect roots of segments downloaded before
+	if err := downloader.collectCompletedSegments(); err != nil {
+		return errors.WithMessage(err, "Failed to collect downloaded segments")
+	}
+
+	downloader.progress.report(PhaseDownloading, downloader.file.Metadata().NumCompleted())
+
+	numTasks := len(downloader.missingSegments)
+	routines := numRoutines(len(downloader.pool.clients), downloader.perNode)
+	bufSize := routines * 2
 	if bufSize < minBufSize {
 		bufSize = minBufSize
 	}
 
-	return parallel.Serial(downloader, int(numTasks), numNodes, bufSize)
+	downloader.ctx = ctx
+	defer func() { downloader.ctx = nil }()
+
+	if err := parallel.UnorderedContext(ctx, downloader, numTasks, routines, bufSize); err != nil {
+		return err
+	}
+
+	downloader.progress.report(PhaseValidating, downloader.numSegments)
+
+	if err := downloader.verifier.verify(ctx, downloader.pool); err != nil {
+		// download the bad segment again on resume
+		var corrupted *SegmentCorruptedError
+		if errors.As(err, &corrupted) {
+			if err := downloader.file.ResetSegment(corrupted.Segment); err != nil {
+				logrus.WithError(err).WithField("segment", corrupted.Segment).Warn("Failed to reset the bad segment")
+			}
+		}
+
+		return errors.WithMessage(err, "Failed to verify downloaded segments")
+	}
+
+	logrus.Info("Succeeded to verify the downloaded segments")
+
+	return nil
+}
+
+func (downloader *SegmentDownloader) collectCompletedSegments() error {
+	metadata := downloader.file.Metadata()
+
+	for i := uint64(0); i < downloader.numSegments; i++ {
+		if !metadata.IsSegmentCompleted(i) {
+			continue
+		}
+
+		data, err := downloader.file.ReadSegment(i)
+		if err != nil {
+			return errors.WithMessagef(err, "Failed to read segment %v", i)
+		}
+
+		downloader.verifier.collect(i, downloader.verifier.segmentRoot(i, data), "")
+	}
+
+	return nil
 }
 
 // ParallelDo implements the parallel.Interface interface.
 func (downloader *SegmentDownloader) ParallelDo(routine, task int) (interface{}, error) {
-	segmentIndex := downloader.segmentOffset + uint64(task)
+	return downloader.ParallelDoContext(context.Background(), routine, task)
+}
+
+// ParallelDoContext implements the parallel.ContextInterface interface.
+func (downloader *SegmentDownloader) ParallelDoContext(ctx context.Context, routine, task int) (interface{}, error) {
+	segmentIndex := downloader.missingSegments[task]
 	startIndex := segmentIndex * DefaultSegmentMaxChunks
 	endIndex := startIndex + DefaultSegmentMaxChunks
 	if endIndex > downloader.numChunks {
@@ -67,33 +177,165 @@ func (downloader *SegmentDownloader) ParallelDo(routine, task int) (interface{},
 
 	root := downloader.file.Metadata().Root
 
+	if err := downloader.bandwidth.wait(ctx, int(endIndex-startIndex)*DefaultChunkSize); err != nil {
+		return nil, err
+	}
+
 	var (
-		segment []byte
-		err     error
+		segment     []byte
+		segmentHash common.Hash // available if validated by proof
+		nodeUrl     string
 	)
 
-	if downloader.withProof {
-		segment, err = downloader.downloadWithProof(downloader.clients[routine], root, startIndex, endIndex)
-	} else {
-		segment, err = downloader.clients[routine].Ionian().DownloadSegment(root, startIndex, endIndex)
-	}
+	// download from the pinned storage node, and fall back to other nodes if failed
+	err := downloader.pool.call(ctx, routine, func(client *node.Client) (err error) {
+		nodeUrl = client.URL()
+
+		if downloader.withProof {
+			segment, segmentHash, err = downloader.downloadWithProof(ctx, client, root, startIndex, endIndex)
+		} else {
+			segment, err = client.Ionian().DownloadSegmentContext(ctx, root, startIndex, endIndex)
+		}
+
+		// fail over to other storage nodes if data truncated, which could not be trimmed
+		if err == nil && uint64(len(segment)) != (endIndex-startIndex)*DefaultChunkSize {
+			err = errors.Errorf("Downloaded data length mismatch, expected = %v, actual = %v", (endIndex-startIndex)*DefaultChunkSize, len(segment))
+		}
+
+		return err
+	})
 
 	if err != nil {
+		err = errors.WithMessagef(err, "Failed to download segment %v from any storage node", segmentIndex)
+
 		logrus.WithError(err).WithFields(logrus.Fields{
+			"node":    nodeUrl,
 			"routine": routine,
 			"segment": fmt.Sprintf("%v/%v", segmentIndex, downloader.numSegments),
 			"chunks":  fmt.Sprintf("[%v, %v)", startIndex, endIndex),
 		}).Error("Failed to download segment")
 	} else if logrus.IsLevelEnabled(logrus.TraceLevel) {
 		logrus.WithFields(logrus.Fields{
+			"node":    nodeUrl,
 			"routine": routine,
 			"segment": fmt.Sprintf("%v/%v", segmentIndex, downloader.numSegments),
 			"chunks":  fmt.Sprintf("[%v, %v)", startIndex, endIndex),
 		}).Trace("Succeeded to download segment")
 	}
 
-	// remove paddings for the last chunk
-	if err == nil && segmentIndex == downloader.numSegments-1 {
+	if err != nil {
+		return nil, err
+	}
+
+	segment = downloader.trimPaddings(segmentIndex, segment)
+
+	// calculate segment root if not validated by proof
+	if !downloader.withProof {
+		segmentHash = downloader.verifier.segmentRoot(segmentIndex, segment)
+	}
+
+	return &downloadedSegment{
+		data:    segment,
+		root:    segmentHash,
+		nodeUrl: nodeUrl,
+	}, nil
+}
+
+// ParallelCollect implements the parallel.Interface interface.
+func (downloader *SegmentDownloader) ParallelCollect(result *parallel.Result) error {
+	segmentIndex := downloader.missingSegments[result.Task]
+	segment := result.Value.(*downloadedSegment)
+
+	if err := downloader.file.WriteSegment(segmentIndex, segment.data); err != nil {
+		return err
+	}
+
+	if downloader.verifier.collect(segmentIndex, segment.root, segment.nodeUrl) {
+		if err := downloader.verifyBlock(segmentIndex); err != nil {
+			return err
+		}
+	}
+
+	downloader.progress.report(PhaseDownloading, downloader.file.Metadata().NumCompleted())
+
+	return nil
+}
+
+// verifyBlock verifies the block that contains the specified segment, and downloads bad segments
+// again with merkle proof from other storage nodes until the block verified.
+func (downloader *SegmentDownloader) verifyBlock(segmentIndex uint64) error {
+	ctx := downloader.ctx
+
+	for {
+		err := downloader.verifier.verifyBlock(ctx, downloader.pool, segmentIndex)
+
+		var corrupted *SegmentCorruptedError
+		if !errors.As(err, &corrupted) {
+			return err
+		}
+
+		// download the bad segment again on resume if failed to download it now
+		if err := downloader.file.ResetSegment(corrupted.Segment); err != nil {
+			return errors.WithMessagef(err, "Failed to reset the bad segment %v", corrupted.Segment)
+		}
+
+		if err := downloader.redownload(ctx, corrupted); err != nil {
+			return errors.WithMessagef(corrupted, "Failed to download the bad segment again: %v", err)
+		}
+	}
+}
+
+// redownload downloads the bad segment again with merkle proof, preferring the storage node next
+// to the one that served the bad segment.
+func (downloader *SegmentDownloader) redownload(ctx context.Context, corrupted *SegmentCorruptedError) error {
+	preferred := 0
+	for i, client := range downloader.pool.clients {
+		if client.URL() == corrupted.Node {
+			downloader.pool.fail(i)
+			preferred = i + 1
+			break
+		}
+	}
+
+	startIndex := corrupted.Segment * DefaultSegmentMaxChunks
+	endIndex := startIndex + DefaultSegmentMaxChunks
+	if endIndex > downloader.numChunks {
+		endIndex = downloader.numChunks
+	}
+
+	var (
+		segment     []byte
+		segmentHash common.Hash
+		nodeUrl     string
+	)
+
+	err := downloader.pool.call(ctx, preferred%len(downloader.pool.clients), func(client *node.Client) (err error) {
+		nodeUrl = client.URL()
+		segment, segmentHash, err = downloader.downloadWithProof(ctx, client, downloader.file.Metadata().Root, startIndex, endIndex)
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"segment": corrupted.Segment,
+		"node":    nodeUrl,
+	}).Info("Succeeded to download the bad segment again")
+
+	if err = downloader.file.WriteSegment(corrupted.Segment, downloader.trimPaddings(corrupted.Segment, segment)); err != nil {
+		return err
+	}
+
+	downloader.verifier.collect(corrupted.Segment, segmentHash, nodeUrl)
+
+	return nil
+}
+
+// trimPaddings removes paddings for the last chunk of file.
+func (downloader *SegmentDownloader) trimPaddings(segmentIndex uint64, segment []byte) []byte {
+	if segmentIndex == downloader.numSegments-1 {
 		fileSize := downloader.file.Metadata().Size
 		if lastChunkSize := fileSize % DefaultChunkSize; lastChunkSize > 0 {
 			paddings := DefaultChunkSize - lastChunkSize
@@ -101,46 +343,49 @@ func (downloader *SegmentDownloader) ParallelDo(routine, task int) (interface{},
 		}
 	}
 
-	return segment, err
+	return segment
 }
 
-// ParallelCollect implements the parallel.Interface interface.
-func (downloader *SegmentDownloader) ParallelCollect(result *parallel.Result) error {
-	return downloader.file.Write(result.Value.([]byte))
+// numRoutines returns the number of routines to download segments in parallel, where routine i
+// prefers storage node i % numNodes.
+func numRoutines(numNodes, perNode int) int {
+	if perNode <= 0 {
+		perNode = 1
+	}
+
+	return numNodes * perNode
+}
+
+func (downloader *SegmentDownloader) downloadWithProof(ctx context.Context, client *node.Client, root common.Hash, startIndex, endIndex uint64) ([]byte, common.Hash, error) {
+	return downloadSegmentWithProof(ctx, client, root, downloader.numChunks, startIndex, endIndex)
 }
 
-func (downloader *SegmentDownloader) downloadWithProof(client *node.Client, root common.Hash, startIndex, endIndex uint64) ([]byte, error) {
+// downloadSegmentWithProof downloads segment of chunks [startIndex, endIndex) along with merkle proof,
+// and validates the proof against file root, where numChunks is the total number of chunks in file.
+// Returns the segment data and the segment root in flow padded merkle tree.
+func downloadSegmentWithProof(ctx context.Context, client *node.Client, root common.Hash, numChunks, startIndex, endIndex uint64) ([]byte, common.Hash, error) {
 	segmentIndex := startIndex / DefaultSegmentMaxChunks
 
-	segment, err := client.Ionian().DownloadSegmentWithProof(root, segmentIndex)
+	segment, err := client.Ionian().DownloadSegmentWithProofContext(ctx, root, segmentIndex)
 	if err != nil {
-		return nil, errors.WithMessage(err, "Failed to download segment with proof from storage node")
+		return nil, common.Hash{}, errors.WithMessage(err, "Failed to download segment with proof from storage node")
 	}
 
 	if expectedDataLen := (endIndex - startIndex) * DefaultChunkSize; int(expectedDataLen) != len(segment.Data) {
-		return nil, errors.Errorf("Downloaded data length mismatch, expected = %v, actual = %v", expectedDataLen, len(segment.Data))
+		return nil, common.Hash{}, errors.Errorf("Downloaded data length mismatch, expected = %v, actual = %v", expectedDataLen, len(segment.Data))
 	}
 
-	numChunksFlowPadded, _ := computePaddedSize(downloader.numChunks)
+	numChunksFlowPadded, _ := computePaddedSize(numChunks)
 	numSegmentsFlowPadded := (numChunksFlowPadded-1)/DefaultSegmentMaxChunks + 1
 
 	// pad empty chunks for the last segment to validate merkle proof
-	var emptyChunksPadded uint64
-	if numChunks := endIndex - startIndex; numChunks < DefaultSegmentMaxChunks {
-		if segmentIndex < numSegmentsFlowPadded-1 {
-			// pad empty chunks to a full segment
-			emptyChunksPadded = DefaultSegmentMaxChunks - numChunks
-		} else if lastSegmentChunks := numChunksFlowPadded % DefaultSegmentMaxChunks; numChunks < lastSegmentChunks {
-			// pad for the last segment with flow padded empty chunks
-			emptyChunksPadded = lastSegmentChunks - numChunks
-		}
-	}
+	paddedChunks := emptyChunksPadded(numChunks, segmentIndex, endIndex-startIndex)
 
-	segmentRootHash := segmentRoot(segment.Data, emptyChunksPadded)
+	segmentRootHash := segmentRoot(segment.Data, paddedChunks)
 
 	if err := segment.Proof.ValidateHash(root, segmentRootHash, segmentIndex, numSegmentsFlowPadded); err != nil {
-		return nil, errors.WithMessage(err, "Failed to validate proof")
+		return nil, common.Hash{}, errors.WithMessage(err, "Failed to validate proof")
 	}
 
-	return segment.Data, nil
+	return segment.Data, segmentRootHash, nil
 }
@@ -0,0 +1,143 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadFailover(t *testing.T) {
+	data := createTestData(5*DefaultSegmentSize + 100)
+
+	good, client1 := newMockNode(t, data)
+	unavailable, client2 := newMockNode(t, data)
+	corrupted, client3 := newMockNode(t, data)
+	unavailable.unavailable = true
+	corrupted.corrupted = true
+
+	downloader := NewDownloader(client1, client2, client3).WithRetry(RetryOption{
+		Interval:        time.Millisecond,
+		MaxNodeFailures: 1,
+	})
+
+	filename := filepath.Join(t.TempDir(), "data")
+	assert.NoError(t, downloader.DownloadContext(context.Background(), good.tree.Root().Hex(), filename, true))
+
+	downloaded, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, data, downloaded)
+
+	// corrupted node benched after the first failure, except for requests already in flight
+	// from the other routine that failed over from the unavailable node
+	assert.LessOrEqual(t, corrupted.downloads, int32(2))
+}
+
+func TestDownloadTruncated(t *testing.T) {
+	data := createTestData(3*DefaultSegmentSize + 100)
+
+	good, client1 := newMockNode(t, data)
+	truncated, client2 := newMockNode(t, data)
+	truncated.truncated = true
+
+	downloader := NewDownloader(client2, client1).WithRetry(RetryOption{
+		Interval:        time.Millisecond,
+		MaxNodeFailures: 1,
+	})
+
+	filename := filepath.Join(t.TempDir(), "data")
+	assert.NoError(t, downloader.DownloadContext(context.Background(), good.tree.Root().Hex(), filename, false))
+
+	downloaded, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, data, downloaded)
+
+	// failed without panic if all storage nodes serve truncated segments
+	downloader = NewDownloader(client2).WithRetry(RetryOption{
+		MaxRetries: 2,
+		Interval:   time.Millisecond,
+	})
+
+	filename = filepath.Join(t.TempDir(), "data")
+	assert.Error(t, downloader.DownloadContext(context.Background(), good.tree.Root().Hex(), filename, false))
+}
+
+func TestDownloadNoNodeAvailable(t *testing.T) {
+	data := createTestData(DefaultSegmentSize + 100)
+
+	mock, client := newMockNode(t, data)
+	mock.unavailable = true
+
+	downloader := NewDownloader(client).WithRetry(RetryOption{
+		MaxRetries: 2,
+		Interval:   time.Millisecond,
+	})
+
+	filename := filepath.Join(t.TempDir(), "data")
+	assert.Error(t, downloader.DownloadContext(context.Background(), mock.tree.Root().Hex(), filename, false))
+}
+
+func TestDownloadFromFinalizedNodes(t *testing.T) {
+	data := createTestData(2*DefaultSegmentSize + 100)
+
+	good, client1 := newMockNode(t, data)
+	notFinalized, client2 := newMockNode(t, data)
+	_, client3 := newMockNode(t, createTestData(100))
+	notFinalized.info.Finalized = false
+
+	downloader := NewDownloader(client1, client2, client3)
+
+	info, clients, err := downloader.queryFile(context.Background(), good.tree.Root())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(len(data)), info.Tx.Size)
+	assert.Equal(t, 1, len(clients))
+	assert.Equal(t, client1.URL(), clients[0].URL())
+
+	filename := filepath.Join(t.TempDir(), "data")
+	assert.NoError(t, downloader.DownloadContext(context.Background(), good.tree.Root().Hex(), filename, false))
+	assert.Equal(t, int32(0), notFinalized.downloads)
+
+	// file not available on any storage node
+	good.info.Finalized = false
+	_, _, err = downloader.queryFile(context.Background(), good.tree.Root())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "file not found")
+	assert.Contains(t, err.Error(), "file not finalized")
+}
+
+func TestDownloadByTxSeq(t *testing.T) {
+	data := createTestData(2*DefaultSegmentSize + 100)
+
+	// the same file uploaded twice, and the duplicated one is not finalized on the second node
+	mock1, client1 := newMockNode(t, data)
+	mock2, client2 := newMockNode(t, data)
+
+	for _, mock := range []*mockNode{mock1, mock2} {
+		mock.info.Tx.Seq = 3
+		duplicated := mock.info
+		duplicated.Tx.Seq = 7
+		mock.txs = map[uint64]node.FileInfo{7: duplicated}
+	}
+
+	info := mock2.txs[7]
+	info.Finalized = false
+	mock2.txs[7] = info
+
+	downloader := NewDownloader(client1, client2)
+
+	filename := filepath.Join(t.TempDir(), "data")
+	assert.NoError(t, downloader.DownloadByTxSeqContext(context.Background(), 7, filename, true))
+	assert.Equal(t, int32(0), mock2.downloads)
+
+	downloaded, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, data, downloaded)
+
+	// transaction not found
+	filename = filepath.Join(t.TempDir(), "data")
+	assert.Error(t, downloader.DownloadByTxSeqContext(context.Background(), 9, filename, true))
+}
@@ -0,0 +1,307 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/file/merkle"
+	"github.com/Ionian-Web3-Storage/ionian-client/node"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// SegmentCorruptedError is returned when the downloaded file mismatches the file root, and indicates
+// the first bad segment along with the storage node that served it.
+type SegmentCorruptedError struct {
+	Segment uint64 // index of the first bad segment
+	Node    string // URL of storage node that served the segment, or empty if downloaded before
+}
+
+func (e *SegmentCorruptedError) Error() string {
+	node := e.Node
+	if len(node) == 0 {
+		node = "local file"
+	}
+
+	return fmt.Sprintf("Merkle root mismatch, the first bad segment is %v served by %v", e.Segment, node)
+}
+
+// defaultVerifyBlockSize is the number of segments in block to verify once all segments of block
+// collected, e.g. 16 MiB, so that a bad segment could be downloaded again in time. Note, it must be
+// power of 2, so that each block is a subtree of the flow padded merkle tree.
+const defaultVerifyBlockSize = 64
+
+// segmentVerifier collects roots of downloaded segments to verify against the file root, so that
+// the downloaded file need not to be read again to build the merkle tree.
+type segmentVerifier struct {
+	root        common.Hash
+	numChunks   uint64
+	numSegments uint64
+
+	roots     []common.Hash // roots of all segments, including flow padded ones
+	nodes     []string      // storage nodes that served segments
+	collected []bool        // whether segment root collected
+
+	blockSize uint64   // number of segments in block to verify
+	remaining []uint64 // number of segments not collected in each block
+}
+
+func newSegmentVerifier(root common.Hash, fileSize int64) *segmentVerifier {
+	numChunks := numSplits(fileSize, DefaultChunkSize)
+	numSegments := numSplits(fileSize, DefaultSegmentSize)
+
+	numChunksFlowPadded, _ := computePaddedSize(numChunks)
+	numSegmentsFlowPadded := (numChunksFlowPadded-1)/DefaultSegmentMaxChunks + 1
+
+	verifier := segmentVerifier{
+		root:        root,
+		numChunks:   numChunks,
+		numSegments: numSegments,
+		roots:       make([]common.Hash, numSegmentsFlowPadded),
+		nodes:       make([]string, numSegments),
+		collected:   make([]bool, numSegments),
+	}
+
+	// roots of flow padded segments with empty chunks only
+	var emptySegmentRoot common.Hash
+	for i := numSegments; i < numSegmentsFlowPadded; i++ {
+		if padded := emptyChunksPadded(numChunks, i, 0); padded < DefaultSegmentMaxChunks {
+			verifier.roots[i] = segmentRoot(nil, padded)
+		} else {
+			if emptySegmentRoot == (common.Hash{}) {
+				emptySegmentRoot = segmentRoot(nil, padded)
+			}
+
+			verifier.roots[i] = emptySegmentRoot
+		}
+	}
+
+	return verifier.withBlockSize(defaultVerifyBlockSize)
+}
+
+// withBlockSize sets the number of segments in block to verify, which must be power of 2.
+func (verifier *segmentVerifier) withBlockSize(blockSize uint64) *segmentVerifier {
+	verifier.blockSize = blockSize
+	verifier.remaining = nil
+
+	for i := uint64(0); i < verifier.numSegments; i += blockSize {
+		var remaining uint64
+		for j := i; j < i+blockSize && j < verifier.numSegments; j++ {
+			if !verifier.collected[j] {
+				remaining++
+			}
+		}
+
+		verifier.remaining = append(verifier.remaining, remaining)
+	}
+
+	return verifier
+}
+
+// segmentRoot calculates the root of specified segment in flow padded merkle tree, and the data of
+// last segment could be unaligned with chunks.
+func (verifier *segmentVerifier) segmentRoot(index uint64, data []byte) common.Hash {
+	if remainder := len(data) % DefaultChunkSize; remainder > 0 {
+		data = append(append([]byte{}, data...), make([]byte, DefaultChunkSize-remainder)...)
+	}
+
+	numSegmentChunks := uint64(len(data) / DefaultChunkSize)
+
+	return segmentRoot(data, emptyChunksPadded(verifier.numChunks, index, numSegmentChunks))
+}
+
+// collect collects the root of specified segment served by the storage node, and returns true if
+// all segments of the block that contains the segment are collected.
+func (verifier *segmentVerifier) collect(index uint64, root common.Hash, nodeUrl string) bool {
+	verifier.roots[index] = root
+	verifier.nodes[index] = nodeUrl
+
+	block := index / verifier.blockSize
+
+	if !verifier.collected[index] {
+		verifier.collected[index] = true
+		verifier.remaining[block]--
+	}
+
+	return verifier.remaining[block] == 0
+}
+
+// verifyBlock verifies the collected segment roots of the block that contains the specified segment,
+// which requires a merkle proof from storage nodes, unless the block is the whole tree. If mismatch,
+// the first bad segment in block is returned in SegmentCorruptedError.
+func (verifier *segmentVerifier) verifyBlock(ctx context.Context, pool *nodePool, index uint64) error {
+	start := index / verifier.blockSize * verifier.blockSize
+	end := start + verifier.blockSize
+	if end > uint64(len(verifier.roots)) {
+		end = uint64(len(verifier.roots))
+	}
+
+	var builder merkle.TreeBuilder
+	for _, root := range verifier.roots[start:end] {
+		builder.AppendHash(root)
+	}
+
+	tree := builder.Build()
+
+	// whole tree in block
+	if start == 0 && end == uint64(len(verifier.roots)) && tree.Root() == verifier.root {
+		return nil
+	}
+
+	bad, found, err := verifier.locate(ctx, pool, tree, start)
+	if err != nil {
+		return errors.WithMessagef(err, "Failed to verify segments in [%v, %v)", start, end)
+	}
+
+	if !found {
+		return nil
+	}
+
+	return verifier.corrupted(bad)
+}
+
+func (verifier *segmentVerifier) corrupted(index uint64) error {
+	logrus.WithFields(logrus.Fields{
+		"segment": index,
+		"node":    verifier.nodes[index],
+	}).Error("Downloaded segment corrupted")
+
+	return &SegmentCorruptedError{index, verifier.nodes[index]}
+}
+
+// verify verifies the collected segment roots against the file root. If mismatch, the first bad
+// segment will be located with merkle proofs from storage nodes, and returned in SegmentCorruptedError.
+func (verifier *segmentVerifier) verify(ctx context.Context, pool *nodePool) error {
+	var builder merkle.TreeBuilder
+	for _, root := range verifier.roots {
+		builder.AppendHash(root)
+	}
+
+	tree := builder.Build()
+	if tree.Root() == verifier.root {
+		return nil
+	}
+
+	index, found, err := verifier.locate(ctx, pool, tree, 0)
+	if err == nil && !found {
+		err = errors.New("No bad segment found")
+	}
+
+	if err != nil {
+		return errors.WithMessagef(err, "Merkle root mismatch, downloaded = %v, and failed to locate the bad segment", tree.Root())
+	}
+
+	return verifier.corrupted(index)
+}
+
+// locate locates the first bad segment in tree, which is a subtree built with downloaded segment roots
+// from the specified offset. It walks from left to right with merkle proofs from storage nodes, and
+// skips sibling subtrees that match. Returns false if all segments in tree match.
+func (verifier *segmentVerifier) locate(ctx context.Context, pool *nodePool, tree *merkle.Tree, offset uint64) (uint64, bool, error) {
+	numSegments := uint64(len(verifier.roots))
+	numLeafNodes := uint64(tree.NumLeafNodes())
+
+	end := offset + numLeafNodes
+	if end > verifier.numSegments {
+		end = verifier.numSegments
+	}
+
+	for index := offset; index < end; {
+		var proof merkle.Proof
+
+		err := pool.call(ctx, 0, func(client *node.Client) error {
+			segment, err := client.Ionian().DownloadSegmentWithProofContext(ctx, verifier.root, index)
+			if err != nil {
+				return errors.WithMessage(err, "Failed to download segment with proof from storage node")
+			}
+
+			if len(segment.Proof.Lemma) == 0 {
+				return errors.New("Empty merkle proof")
+			}
+
+			if err = segment.Proof.ValidateHash(verifier.root, segment.Proof.Lemma[0], index, numSegments); err != nil {
+				return errors.WithMessage(err, "Failed to validate proof")
+			}
+
+			proof = segment.Proof
+
+			return nil
+		})
+
+		if err != nil {
+			return 0, false, errors.WithMessagef(err, "Failed to get merkle proof of segment %v", index)
+		}
+
+		// leaf node mismatch
+		if verifier.roots[index] != proof.Lemma[0] {
+			return index, true, nil
+		}
+
+		// locate the lowest sibling subtree on the right side that mismatch, and the lower
+		// levels of proof are consistent with the subtree
+		local := tree.ProofAt(int(index - offset))
+		siblings := siblingRanges(index-offset, numLeafNodes)
+		next := index
+
+		for i, isLeft := range local.Path {
+			if isLeft && local.Lemma[i+1] != proof.Lemma[i+1] {
+				next = offset + siblings[i]
+				break
+			}
+		}
+
+		if next == index {
+			break
+		}
+
+		index = next
+	}
+
+	return 0, false, nil
+}
+
+// siblingRanges returns the first leaf index of sibling subtrees from bottom to top along the path
+// of specified leaf, which is consistent with merkle.Proof.
+func siblingRanges(index, numLeafNodes uint64) []uint64 {
+	var siblings []uint64
+
+	for start, n := uint64(0), numLeafNodes; n > 1; {
+		leftSideLeafNodes := uint64(1) << (bits.Len64(n-1) - 1)
+
+		if index < start+leftSideLeafNodes {
+			siblings = append(siblings, start+leftSideLeafNodes)
+			n = leftSideLeafNodes
+		} else {
+			siblings = append(siblings, start)
+			start += leftSideLeafNodes
+			n -= leftSideLeafNodes
+		}
+	}
+
+	// reverse to bottom up
+	for i, j := 0, len(siblings)-1; i < j; i, j = i+1, j-1 {
+		siblings[i], siblings[j] = siblings[j], siblings[i]
+	}
+
+	return siblings
+}
+
+// emptyChunksPadded returns the number of empty chunks to pad for the specified segment that contains
+// numSegmentChunks chunks, so as to calculate the segment root in flow padded merkle tree, where numChunks
+// is the total number of chunks in file.
+func emptyChunksPadded(numChunks, segmentIndex, numSegmentChunks uint64) uint64 {
+	numChunksFlowPadded, _ := computePaddedSize(numChunks)
+
+	segmentPaddedChunks := numChunksFlowPadded - segmentIndex*DefaultSegmentMaxChunks
+	if segmentPaddedChunks > DefaultSegmentMaxChunks {
+		segmentPaddedChunks = DefaultSegmentMaxChunks
+	}
+
+	if numSegmentChunks >= segmentPaddedChunks {
+		return 0
+	}
+
+	return segmentPaddedChunks - numSegmentChunks
+}
@@ -0,0 +1,137 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/node"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentVerifier(t *testing.T) {
+	// includes the case that the last segment is padded to a full segment in flow
+	for _, size := range []int64{1, 300, DefaultSegmentSize, 3*DefaultSegmentSize + 100, 2047 * DefaultChunkSize, 9*DefaultSegmentSize + 1} {
+		data := createTestData(int(size))
+
+		file, err := OpenReaderAt(bytes.NewReader(data), size)
+		assert.NoError(t, err)
+
+		tree, err := file.MerkleTree()
+		assert.NoError(t, err)
+
+		verifier := newSegmentVerifier(tree.Root(), size)
+		for i := uint64(0); i < verifier.numSegments; i++ {
+			end := int64(i+1) * DefaultSegmentSize
+			if end > size {
+				end = size
+			}
+
+			segment := data[int64(i)*DefaultSegmentSize : end]
+			verifier.collect(i, verifier.segmentRoot(i, segment), "")
+		}
+
+		assert.NoError(t, verifier.verify(context.Background(), nil), "size = %v", size)
+	}
+}
+
+func TestSiblingRanges(t *testing.T) {
+	assert.Equal(t, []uint64{1, 2, 4}, siblingRanges(0, 5))
+	assert.Equal(t, []uint64{2, 0, 4}, siblingRanges(3, 5))
+	assert.Equal(t, []uint64{0}, siblingRanges(4, 5))
+	assert.Empty(t, siblingRanges(0, 1))
+}
+
+func TestDownloadSegmentCorrupted(t *testing.T) {
+	data := createTestData(9*DefaultSegmentSize + 100)
+
+	mock, client := newMockNode(t, data)
+	mock.badSegments = map[uint64]bool{5: true, 7: true}
+
+	downloader := NewDownloader(client).WithRetry(RetryOption{
+		MaxRetries: 2,
+		Interval:   time.Millisecond,
+	})
+	filename := filepath.Join(t.TempDir(), "data")
+
+	// failed to download the bad segment again from the only storage node
+	err := downloader.DownloadContext(context.Background(), mock.tree.Root().Hex(), filename, false)
+
+	var corrupted *SegmentCorruptedError
+	assert.True(t, errors.As(err, &corrupted))
+	assert.Equal(t, uint64(5), corrupted.Segment)
+	assert.Equal(t, client.URL(), corrupted.Node)
+
+	// the bad segment downloaded on resume, and the next bad one downloaded again with proof in time
+	mock.badSegments = nil
+	downloads, proofs := mock.downloads, mock.proofs
+	assert.NoError(t, downloader.DownloadContext(context.Background(), mock.tree.Root().Hex(), filename, false))
+	assert.Equal(t, downloads-proofs+1, mock.downloads-mock.proofs)
+
+	downloaded, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, data, downloaded)
+}
+
+func TestDownloadSegmentCorruptedRefetch(t *testing.T) {
+	data := createTestData(9*DefaultSegmentSize + 100)
+
+	mock1, client1 := newMockNode(t, data)
+	mock1.corrupted = true
+	_, client2 := newMockNode(t, data)
+
+	downloader := NewDownloader(client1, client2).WithRetry(RetryOption{
+		MaxRetries: 2,
+		Interval:   time.Millisecond,
+	})
+	filename := filepath.Join(t.TempDir(), "data")
+
+	// bad segments downloaded again from the other storage node in time
+	assert.NoError(t, downloader.DownloadContext(context.Background(), mock1.tree.Root().Hex(), filename, false))
+
+	downloaded, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, data, downloaded)
+}
+
+func TestSegmentVerifierBlock(t *testing.T) {
+	data := createTestData(9*DefaultSegmentSize + 100)
+
+	mock, client := newMockNode(t, data)
+	pool := newFailoverNodePool([]*node.Client{client}, RetryOption{})
+
+	verifier := newSegmentVerifier(mock.tree.Root(), int64(len(data))).withBlockSize(4)
+	assert.Equal(t, []uint64{4, 4, 2}, verifier.remaining)
+
+	for i := uint64(0); i < verifier.numSegments; i++ {
+		end := int64(i+1) * DefaultSegmentSize
+		if end > int64(len(data)) {
+			end = int64(len(data))
+		}
+
+		segment := append([]byte{}, data[int64(i)*DefaultSegmentSize:end]...)
+		if i == 6 {
+			segment[0] ^= 1
+		}
+
+		complete := verifier.collect(i, verifier.segmentRoot(i, segment), client.URL())
+		assert.Equal(t, i%4 == 3 || i == verifier.numSegments-1, complete, "segment = %v", i)
+
+		if !complete {
+			continue
+		}
+
+		err := verifier.verifyBlock(context.Background(), pool, i)
+		if i/4 == 1 {
+			var corrupted *SegmentCorruptedError
+			assert.True(t, errors.As(err, &corrupted))
+			assert.Equal(t, uint64(6), corrupted.Segment)
+		} else {
+			assert.NoError(t, err, "segment = %v", i)
+		}
+	}
+}
@@ -1,9 +1,14 @@
 package file
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"strings"
+	"sync"
 
 	"github.com/Ionian-Web3-Storage/ionian-client/file/download"
+	"github.com/Ionian-Web3-Storage/ionian-client/file/encryption"
 	"github.com/Ionian-Web3-Storage/ionian-client/node"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/pkg/errors"
@@ -11,7 +16,18 @@ import (
 )
 
 type Downloader struct {
-	clients []*node.Client
+	clients    []*node.Client
+	progress   ProgressListener
+	decryption *encryption.Key
+	parts      bool // reassemble parts if downloaded file is a parts manifest
+	retry      RetryOption
+
+	perNode   int  // number of in-flight requests per storage node
+	adaptive  bool // adjust in-flight requests based on latency and errors
+	bandwidth *bandwidthLimiter
+
+	// pool is shared by files downloaded in batch, and nil to create pool for each file
+	pool *nodePool
 }
 
 func NewDownloader(clients ...*node.Client) *Downloader {
@@ -24,53 +40,300 @@ func NewDownloader(clients ...*node.Client) *Downloader {
 	}
 }
 
+// WithProgress sets the listener to receive progress events during download.
+func (downloader *Downloader) WithProgress(listener ProgressListener) *Downloader {
+	downloader.progress = listener
+	return downloader
+}
+
+// WithRetry sets the retry policy to download segments, and failed segment will be retried
+// on other storage nodes.
+func (downloader *Downloader) WithRetry(option RetryOption) *Downloader {
+	downloader.retry = option
+	return downloader
+}
+
+// WithConcurrency sets the max number of in-flight requests per storage node, default 1. In adaptive
+// mode, in-flight requests are adjusted based on latency and errors, see SegmentDownloader.WithConcurrency
+// for more details.
+func (downloader *Downloader) WithConcurrency(perNode int, adaptive bool) *Downloader {
+	downloader.perNode = perNode
+	downloader.adaptive = adaptive
+	return downloader
+}
+
+// WithBandwidth limits the download bandwidth in bytes per second, which is shared by all files
+// downloaded by the downloader. 0 for unlimited.
+func (downloader *Downloader) WithBandwidth(bytesPerSecond int64) *Downloader {
+	downloader.bandwidth = newBandwidthLimiter(bytesPerSecond)
+	return downloader
+}
+
+// WithDecryption sets the key to decrypt file once downloaded, which is encrypted before upload.
+func (downloader *Downloader) WithDecryption(key *encryption.Key) *Downloader {
+	downloader.decryption = key
+	return downloader
+}
+
+// WithParts sets whether to download and reassemble all parts once the downloaded file is a parts
+// manifest, which ties the parts of a large file together. By default, parts manifest is downloaded
+// as a regular file.
+func (downloader *Downloader) WithParts(reassemble bool) *Downloader {
+	downloader.parts = reassemble
+	return downloader
+}
+
 func (downloader *Downloader) Download(root, filename string, proof bool) error {
-	hash := common.HexToHash(root)
+	return downloader.DownloadContext(context.Background(), root, filename, proof)
+}
+
+// DownloadContext downloads file from storage nodes, and terminates once the specified context is done.
+//
+// Note, if the specified root is a parts manifest, all parts will be downloaded and reassembled
+// when enabled by WithParts.
+func (downloader *Downloader) DownloadContext(ctx context.Context, root, filename string, proof bool) error {
+	return downloader.downloadAndAssemble(ctx, queryByRoot(common.HexToHash(root)), filename, proof)
+}
+
+func (downloader *Downloader) DownloadByTxSeq(txSeq uint64, filename string, proof bool) error {
+	return downloader.DownloadByTxSeqContext(context.Background(), txSeq, filename, proof)
+}
+
+// DownloadByTxSeqContext downloads file of the specified transaction sequence number, of which the
+// file root and size are resolved from storage nodes.
+//
+// Note, the same file may be uploaded multiple times with different sequence numbers, so only storage
+// nodes that have the specified transaction finalized are used to download file.
+func (downloader *Downloader) DownloadByTxSeqContext(ctx context.Context, txSeq uint64, filename string, proof bool) error {
+	return downloader.downloadAndAssemble(ctx, queryByTxSeq(txSeq), filename, proof)
+}
+
+// encryptedFileSuffix is the suffix of temp file to download encrypted data, which is renamed
+// to the specified file once decrypted.
+const encryptedFileSuffix = ".encrypted"
+
+// downloadAndAssemble downloads file by the specified query, and then reassembles parts and decrypts
+// the downloaded file if required.
+func (downloader *Downloader) downloadAndAssemble(ctx context.Context, query fileQuery, filename string, proof bool) error {
+	// Download encrypted data into a temp file, which is kept if failed to decrypt, e.g. wrong key,
+	// so that decryption could be retried without downloading again.
+	downloadFilename := filename
+	if downloader.decryption != nil {
+		if exists, err := Exists(filename); err != nil {
+			return errors.WithMessage(err, "Failed to check file existence")
+		} else if exists {
+			return errors.WithMessage(ErrFileAlreadyExists, "Decrypted file already exists")
+		}
+
+		downloadFilename = filename + encryptedFileSuffix
+	}
+
+	info, err := downloader.download(ctx, query, downloadFilename, proof)
 
+	// parts manifest is kept until reassembled, and encrypted data is kept until decrypted, so that
+	// interrupted download could be resumed
+	existed := (downloader.parts || downloader.decryption != nil) && errors.Is(err, ErrFileAlreadyExists)
+	if err != nil && !existed {
+		return err
+	}
+
+	size := int64(info.Tx.Size)
+
+	// Reassemble parts of large file if required
+	manifest, readErr := readPartsManifest(downloadFilename, size)
+	if readErr != nil {
+		return errors.WithMessage(readErr, "Failed to read parts manifest")
+	}
+
+	if existed && manifest == nil && downloader.decryption == nil {
+		return err
+	}
+
+	reassembled := false
+	if manifest != nil && !downloader.parts {
+		logrus.WithField("file", filename).Warn("Downloaded file is a parts manifest, enable parts download to reassemble")
+	} else if manifest != nil {
+		if size, err = downloader.downloadParts(ctx, manifest, downloadFilename, proof); err != nil {
+			return errors.WithMessage(err, "Failed to download parts")
+		}
+
+		reassembled = true
+	}
+
+	reporter := newProgressReporter(downloader.progress, info.Tx.DataMerkleRoot, size)
+
+	// Decrypt the downloaded file if required
+	if downloader.decryption != nil {
+		reporter.report(PhaseDecrypting, numSplits(size, DefaultSegmentSize))
+		if err = encryption.DecryptFile(downloader.decryption, downloadFilename, filename); err != nil {
+			// reassembled data could not be resumed against the manifest root
+			if reassembled {
+				os.Remove(downloadFilename)
+			}
+
+			return errors.WithMessage(err, "Failed to decrypt downloaded file")
+		}
+
+		if err = os.Remove(downloadFilename); err != nil {
+			logrus.WithError(err).WithField("file", downloadFilename).Warn("Failed to remove encrypted file")
+		}
+	}
+
+	reporter.report(PhaseCompleted, numSplits(size, DefaultSegmentSize))
+
+	return nil
+}
+
+// download downloads file by the specified query, and returns the file info. Note, downloaded
+// segments are verified against the file root during download.
+//
+// File info is also returned along with ErrFileAlreadyExists if file already downloaded.
+func (downloader *Downloader) download(ctx context.Context, query fileQuery, filename string, proof bool) (*node.FileInfo, error) {
 	// Query file info from storage node
-	info, err := downloader.queryFile(hash)
+	info, clients, err := downloader.queryFileBy(ctx, query)
 	if err != nil {
-		return errors.WithMessage(err, "Failed to query file info")
+		return nil, errors.WithMessage(err, "Failed to query file info")
 	}
 
+	hash := info.Tx.DataMerkleRoot
+
 	// Check file existence before downloading
-	if err = downloader.checkExistence(filename, hash); err != nil {
-		return errors.WithMessage(err, "Failed to check file existence")
+	if err = downloader.checkExistence(filename, hash); errors.Is(err, ErrFileAlreadyExists) {
+		return info, err
+	} else if err != nil {
+		return nil, errors.WithMessage(err, "Failed to check file existence")
 	}
 
 	// Download segments
-	if err = downloader.downloadFile(filename, hash, int64(info.Tx.Size), proof); err != nil {
-		return errors.WithMessage(err, "Failed to download file")
+	reporter := newProgressReporter(downloader.progress, hash, int64(info.Tx.Size))
+	if err = downloader.downloadFile(ctx, clients, filename, hash, int64(info.Tx.Size), proof, reporter); err != nil {
+		return nil, errors.WithMessage(err, "Failed to download file")
 	}
 
-	// Validate the downloaded file
-	if err = downloader.validateDownloadFile(root, filename, int64(info.Tx.Size)); err != nil {
-		return errors.WithMessage(err, "Failed to validate downloaded file")
-	}
+	return info, nil
+}
 
-	return nil
+// fileQuery queries file info from the specified storage node.
+type fileQuery struct {
+	desc  string
+	query func(ctx context.Context, client *node.Client) (*node.FileInfo, error)
 }
 
-func (downloader *Downloader) queryFile(root common.Hash) (info *node.FileInfo, err error) {
-	// requires file finalized on all storage nodes
-	for _, v := range downloader.clients {
-		info, err = v.Ionian().GetFileInfo(root)
-		if err != nil {
-			return nil, errors.WithMessagef(err, "Failed to get file info on node %v", v.URL())
+func queryByRoot(root common.Hash) fileQuery {
+	return fileQuery{"root hash", func(ctx context.Context, client *node.Client) (*node.FileInfo, error) {
+		return client.Ionian().GetFileInfoContext(ctx, root)
+	}}
+}
+
+// withSize wraps the query to skip storage nodes that have file of a different size.
+func (query fileQuery) withSize(size int64) fileQuery {
+	return fileQuery{query.desc, func(ctx context.Context, client *node.Client) (*node.FileInfo, error) {
+		info, err := query.query(ctx, client)
+		if err == nil && info != nil && int64(info.Tx.Size) != size {
+			return nil, errors.Errorf("file size mismatch, expected = %v, actual = %v", size, info.Tx.Size)
 		}
 
-		if info == nil {
-			return nil, errors.WithMessagef(err, "File not found on node %v", v.URL())
+		return info, err
+	}}
+}
+
+func queryByTxSeq(txSeq uint64) fileQuery {
+	return fileQuery{"tx seq", func(ctx context.Context, client *node.Client) (*node.FileInfo, error) {
+		return client.Ionian().GetFileInfoByTxSeqContext(ctx, txSeq)
+	}}
+}
+
+// queryFile probes all storage nodes in parallel, and returns the file info along with storage
+// nodes that have the file finalized. Other storage nodes are skipped with reasons logged.
+func (downloader *Downloader) queryFile(ctx context.Context, root common.Hash) (*node.FileInfo, []*node.Client, error) {
+	return downloader.queryFileBy(ctx, queryByRoot(root))
+}
+
+// queryFileBy probes all storage nodes in parallel by the specified query, see queryFile for more details.
+func (downloader *Downloader) queryFileBy(ctx context.Context, query fileQuery) (*node.FileInfo, []*node.Client, error) {
+	infos := make([]*node.FileInfo, len(downloader.clients))
+	errs := make([]error, len(downloader.clients))
+
+	var wg sync.WaitGroup
+	for i, client := range downloader.clients {
+		wg.Add(1)
+
+		go func(i int, client *node.Client) {
+			defer wg.Done()
+			infos[i], errs[i] = query.query(ctx, client)
+		}(i, client)
+	}
+	wg.Wait()
+
+	var (
+		info    *node.FileInfo
+		clients []*node.Client
+		skipped []string
+	)
+
+	for i, client := range downloader.clients {
+		var reason string
+
+		switch {
+		case errs[i] != nil:
+			reason = errs[i].Error()
+		case infos[i] == nil:
+			reason = "file not found"
+		case !infos[i].Finalized:
+			reason = "file not finalized"
+		case info != nil && infos[i].Tx.DataMerkleRoot != info.Tx.DataMerkleRoot:
+			reason = fmt.Sprintf("file root mismatch, expected = %v, actual = %v", info.Tx.DataMerkleRoot, infos[i].Tx.DataMerkleRoot)
+		case info != nil && infos[i].Tx.Size != info.Tx.Size:
+			reason = fmt.Sprintf("file size mismatch, expected = %v, actual = %v", info.Tx.Size, infos[i].Tx.Size)
+		}
+
+		if len(reason) > 0 {
+			logrus.WithFields(logrus.Fields{
+				"node":   client.URL(),
+				"reason": reason,
+			}).Warn("Storage node skipped to download file")
+
+			skipped = append(skipped, fmt.Sprintf("%v: %v", client.URL(), reason))
+			continue
 		}
 
-		if !info.Finalized {
-			return nil, errors.WithMessagef(err, "File not finalized on node %v", v.URL())
+		if info == nil {
+			info = infos[i]
 		}
+
+		clients = append(clients, client)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(clients) == 0 {
+		return nil, nil, errors.Errorf("File not available on any storage node [%v]", strings.Join(skipped, "; "))
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"file":    info,
+		"nodes":   len(clients),
+		"skipped": len(skipped),
+	}).Debugf("File found by %v", query.desc)
+
+	return info, clients, nil
+}
+
+// nodePool returns a pool of the specified storage nodes to download file, which is a view of the
+// shared pool if downloaded in batch.
+func (downloader *Downloader) nodePool(clients []*node.Client) *nodePool {
+	if downloader.pool != nil {
+		return downloader.pool.view(clients)
 	}
 
-	logrus.WithField("file", info).Debug("File found by root hash")
+	pool := newFailoverNodePool(clients, downloader.retry)
+	if downloader.perNode > 1 || downloader.adaptive {
+		pool.withConcurrency(downloader.perNode, downloader.adaptive)
+	}
 
-	return
+	return pool
 }
 
 func (downloader *Downloader) checkExistence(filename string, hash common.Hash) error {
@@ -85,33 +348,37 @@ func (downloader *Downloader) checkExistence(filename string, hash common.Hash)
 
 	defer file.Close()
 
-	tree, err := file.MerkleTree()
+	root, err := file.MerkleRoot()
 	if err != nil {
-		return errors.WithMessage(err, "Failed to create file merkle tree")
+		return errors.WithMessage(err, "Failed to calculate file merkle root")
 	}
 
-	if tree.Root().Hex() == hash.Hex() {
-		return errors.New("File already exists")
+	if root == hash {
+		return ErrFileAlreadyExists
 	}
 
 	return errors.New("File already exists with different hash")
 }
 
-func (downloader *Downloader) downloadFile(filename string, root common.Hash, size int64, proof bool) error {
+func (downloader *Downloader) downloadFile(ctx context.Context, clients []*node.Client, filename string, root common.Hash, size int64, proof bool, reporter *progressReporter) error {
 	file, err := download.CreateDownloadingFile(filename, root, size)
 	if err != nil {
 		return errors.WithMessage(err, "Failed to create downloading file")
 	}
 	defer file.Close()
 
-	logrus.WithField("threads", len(downloader.clients)).Info("Begin to download file from storage node")
+	logrus.WithField("threads", len(clients)).Info("Begin to download file from storage node")
 
-	sd, err := NewSegmentDownloader(downloader.clients, file, proof)
+	sd, err := NewSegmentDownloader(clients, file, proof)
 	if err != nil {
 		return errors.WithMessage(err, "Failed to create segment downloader")
 	}
+	sd.progress = reporter
+	sd.pool = downloader.nodePool(clients)
+	sd.perNode = downloader.perNode
+	sd.bandwidth = downloader.bandwidth
 
-	if err = sd.Download(); err != nil {
+	if err = sd.DownloadContext(ctx); err != nil {
 		return errors.WithMessage(err, "Failed to download file")
 	}
 
@@ -123,28 +390,3 @@ func (downloader *Downloader) downloadFile(filename string, root common.Hash, si
 
 	return nil
 }
-
-func (downloader *Downloader) validateDownloadFile(root, filename string, fileSize int64) error {
-	file, err := Open(filename)
-	if err != nil {
-		return errors.WithMessage(err, "Failed to open file")
-	}
-	defer file.Close()
-
-	if file.Size() != fileSize {
-		return errors.Errorf("File size mismatch: expected = %v, downloaded = %v", fileSize, file.Size())
-	}
-
-	tree, err := file.MerkleTree()
-	if err != nil {
-		return errors.WithMessage(err, "Failed to create merkle tree")
-	}
-
-	if rootHex := tree.Root().Hex(); rootHex != root {
-		return errors.Errorf("Merkle root mismatch, downloaded = %v", rootHex)
-	}
-
-	logrus.Info("Succeeded to validate the downloaded file")
-
-	return nil
-}
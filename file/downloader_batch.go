@@ -0,0 +1,132 @@
+package file
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// BatchStatusDownloaded is the status of file downloaded in batch.
+const BatchStatusDownloaded = "downloaded"
+
+// DownloadRequest specifies a file to download in batch.
+type DownloadRequest struct {
+	Root     common.Hash
+	Filename string
+	Size     int64 // expected file size on storage nodes, 0 if unknown
+}
+
+// BatchDownloadResult is the download result of a file in batch.
+type BatchDownloadResult struct {
+	Root   common.Hash `json:"root"`
+	File   string      `json:"file"`
+	Status string      `json:"status"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// DownloadMany downloads multiple files in parallel for at most concurrency files. All files share
+// the same storage node pool, so that failures and in-flight requests of storage nodes are tracked
+// across files. Files that already exist with the same root will be skipped.
+//
+// Returns the download result of each file in order, and error only if the specified context done.
+func (downloader *Downloader) DownloadMany(ctx context.Context, requests []DownloadRequest, concurrency uint, proof bool) ([]BatchDownloadResult, error) {
+	if concurrency == 0 {
+		concurrency = 1
+	}
+
+	// shares node pool for all files
+	batch := *downloader
+	batch.pool = downloader.nodePool(downloader.clients)
+
+	results := make([]BatchDownloadResult, len(requests))
+	tasks := make(chan int, concurrency)
+
+	var wg sync.WaitGroup
+	for i := uint(0); i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for index := range tasks {
+				results[index] = batch.downloadBatchTask(ctx, requests[index], proof)
+			}
+		}()
+	}
+
+	for i := range requests {
+		if ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+		case tasks <- i:
+		}
+	}
+
+	close(tasks)
+	wg.Wait()
+
+	// mark files not downloaded due to context done
+	var err error
+	for i := range results {
+		if len(results[i].Status) == 0 {
+			err = ctx.Err()
+			results[i] = BatchDownloadResult{
+				Root:   requests[i].Root,
+				File:   requests[i].Filename,
+				Status: BatchStatusFailed,
+				Error:  err.Error(),
+			}
+		}
+	}
+
+	return results, err
+}
+
+func (downloader *Downloader) downloadBatchTask(ctx context.Context, request DownloadRequest, proof bool) BatchDownloadResult {
+	result := BatchDownloadResult{
+		Root: request.Root,
+		File: request.Filename,
+	}
+
+	logger := logrus.WithFields(logrus.Fields{
+		"root": request.Root,
+		"file": request.Filename,
+	})
+
+	// skip files that already downloaded before querying storage nodes, except parts manifest to resume.
+	// Note, decrypted file could not be checked against the root of encrypted data.
+	var err error
+	if downloader.decryption == nil {
+		err = downloader.checkExistence(request.Filename, request.Root)
+	}
+
+	if err == nil || (downloader.parts && errors.Is(err, ErrFileAlreadyExists)) {
+		query := queryByRoot(request.Root)
+		if request.Size > 0 {
+			query = query.withSize(request.Size)
+		}
+
+		err = downloader.downloadAndAssemble(ctx, query, request.Filename, proof)
+	}
+
+	switch {
+	case err == nil:
+		result.Status = BatchStatusDownloaded
+		logger.Info("File downloaded in batch")
+	case errors.Is(err, ErrFileAlreadyExists):
+		result.Status = BatchStatusExists
+		logger.Info("File already exists in batch")
+	default:
+		result.Status = BatchStatusFailed
+		result.Error = err.Error()
+		logger.WithError(err).Warn("Failed to download file in batch")
+	}
+
+	return result
+}
@@ -0,0 +1,60 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadMany(t *testing.T) {
+	data1 := createTestData(3*DefaultSegmentSize + 100)
+	data2 := createTestData(DefaultSegmentSize + 1)
+
+	mock1, client1 := newMockNode(t, data1)
+	mock2, client2 := newMockNode(t, data2)
+
+	dir := t.TempDir()
+
+	// file already downloaded
+	existing := filepath.Join(dir, "existing")
+	assert.NoError(t, os.WriteFile(existing, data1, 0644))
+
+	requests := []DownloadRequest{
+		{Root: mock1.tree.Root(), Filename: filepath.Join(dir, "file1")},
+		{Root: mock2.tree.Root(), Filename: filepath.Join(dir, "file2"), Size: int64(len(data2))},
+		{Root: mock1.tree.Root(), Filename: existing},
+		{Root: common.HexToHash("0x1234"), Filename: filepath.Join(dir, "file3")},
+		{Root: mock2.tree.Root(), Filename: filepath.Join(dir, "file4"), Size: 100},
+	}
+
+	downloader := NewDownloader(client1, client2)
+	results, err := downloader.DownloadMany(context.Background(), requests, 2, true)
+	assert.NoError(t, err)
+	assert.Equal(t, len(requests), len(results))
+
+	expectedStatus := []string{BatchStatusDownloaded, BatchStatusDownloaded, BatchStatusExists, BatchStatusFailed, BatchStatusFailed}
+	for i, result := range results {
+		assert.Equal(t, requests[i].Root, result.Root)
+		assert.Equal(t, requests[i].Filename, result.File)
+		assert.Equal(t, expectedStatus[i], result.Status, result.Error)
+	}
+
+	downloaded, err := os.ReadFile(requests[0].Filename)
+	assert.NoError(t, err)
+	assert.Equal(t, data1, downloaded)
+
+	downloaded, err = os.ReadFile(requests[1].Filename)
+	assert.NoError(t, err)
+	assert.Equal(t, data2, downloaded)
+
+	// all canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err = downloader.DownloadMany(ctx, requests[3:4], 1, false)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, BatchStatusFailed, results[0].Status)
+}
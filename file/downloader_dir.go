@@ -0,0 +1,109 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// DownloadDir downloads the manifest of specified root, and then downloads all files in manifest
+// to rebuild the folder. Files that already downloaded will be skipped.
+func (downloader *Downloader) DownloadDir(ctx context.Context, root, dir string, proof bool) error {
+	manifest, err := downloader.downloadManifest(ctx, root, proof)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to download manifest")
+	}
+
+	logrus.WithField("entries", len(manifest.Entries)).Info("Begin to download folder")
+
+	var folders []ManifestEntry
+
+	for _, entry := range manifest.Entries {
+		target := filepath.Join(dir, filepath.FromSlash(entry.Path))
+
+		if entry.Mode.IsDir() {
+			if err = os.MkdirAll(target, 0755); err != nil {
+				return errors.WithMessagef(err, "Failed to create folder %v", target)
+			}
+
+			folders = append(folders, entry)
+			continue
+		}
+
+		if err = os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return errors.WithMessagef(err, "Failed to create folder for %v", target)
+		}
+
+		if err = downloader.downloadDirFile(ctx, entry, target, proof); err != nil {
+			return errors.WithMessagef(err, "Failed to download file %v", entry.Path)
+		}
+
+		if err = os.Chmod(target, entry.Mode.Perm()); err != nil {
+			return errors.WithMessagef(err, "Failed to change mode of file %v", target)
+		}
+	}
+
+	// change mode of folders at last, which may be read only
+	for i := len(folders) - 1; i >= 0; i-- {
+		target := filepath.Join(dir, filepath.FromSlash(folders[i].Path))
+		if err = os.Chmod(target, folders[i].Mode.Perm()); err != nil {
+			return errors.WithMessagef(err, "Failed to change mode of folder %v", target)
+		}
+	}
+
+	logrus.Info("Completed to download folder")
+
+	return nil
+}
+
+func (downloader *Downloader) downloadManifest(ctx context.Context, root string, proof bool) (*Manifest, error) {
+	tmpDir, err := os.MkdirTemp("", "ionian-manifest-*")
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to create temp folder")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	filename := filepath.Join(tmpDir, "manifest")
+	if err = downloader.DownloadContext(ctx, root, filename, proof); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to read manifest")
+	}
+
+	manifest, err := DecodeManifest(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.Type != ManifestTypeDir {
+		return nil, errors.Errorf("Not a folder manifest, type = %v", manifest.Type)
+	}
+
+	return manifest, nil
+}
+
+func (downloader *Downloader) downloadDirFile(ctx context.Context, entry ManifestEntry, filename string, proof bool) error {
+	// create empty file directly
+	if entry.Size == 0 {
+		file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+
+		return file.Close()
+	}
+
+	err := downloader.DownloadContext(ctx, entry.Root.Hex(), filename, proof)
+	if errors.Is(err, ErrFileAlreadyExists) {
+		logrus.WithField("file", filename).Info("File already downloaded")
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,131 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// maxPartsManifestSize is the max size of parts manifest, e.g. about 10K parts.
+const maxPartsManifestSize = 1024 * 1024
+
+// readPartsManifest reads the parts manifest from the specified file, and returns nil if the
+// file is not a parts manifest.
+func readPartsManifest(filename string, size int64) (*Manifest, error) {
+	if size > maxPartsManifestSize {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsPartsManifest(data) {
+		return nil, nil
+	}
+
+	manifest, err := DecodeManifest(data)
+	if err != nil {
+		// regular file that looks like a manifest
+		return nil, nil
+	}
+
+	return manifest, nil
+}
+
+// downloadParts downloads all parts in manifest, and then reassembles parts into the specified
+// file in place of the manifest. Returns the size of reassembled file.
+//
+// Note, the manifest is only replaced once all parts reassembled, and parts already downloaded
+// will be skipped when resumed.
+func (downloader *Downloader) downloadParts(ctx context.Context, manifest *Manifest, filename string, proof bool) (int64, error) {
+	logrus.WithField("parts", len(manifest.Entries)).Info("Begin to download file in parts")
+
+	partFilenames := make([]string, 0, len(manifest.Entries))
+
+	for i, entry := range manifest.Entries {
+		partFilename := fmt.Sprintf("%v.part%v", filename, i)
+
+		_, err := downloader.download(ctx, queryByRoot(entry.Root), partFilename, proof)
+		if errors.Is(err, ErrFileAlreadyExists) {
+			logrus.WithField("part", i).Info("Part already downloaded")
+		} else if err != nil {
+			return 0, errors.WithMessagef(err, "Failed to download part %v", i)
+		}
+
+		partFilenames = append(partFilenames, partFilename)
+	}
+
+	size, err := reassembleParts(manifest, partFilenames, filename)
+	if err != nil {
+		return 0, errors.WithMessage(err, "Failed to reassemble parts")
+	}
+
+	for _, partFilename := range partFilenames {
+		if err = os.Remove(partFilename); err != nil {
+			logrus.WithError(err).WithField("part", partFilename).Warn("Failed to remove part file")
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"parts": len(manifest.Entries),
+		"size":  size,
+	}).Info("Completed to download file in parts")
+
+	return size, nil
+}
+
+// reassembleParts concatenates part files in order into a temp file, which is then renamed to the
+// specified file.
+func reassembleParts(manifest *Manifest, partFilenames []string, filename string) (int64, error) {
+	tmpFilename := filename + ".reassembling"
+
+	file, err := os.Create(tmpFilename)
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := copyParts(manifest, partFilenames, file)
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(tmpFilename)
+		return 0, err
+	}
+
+	return size, os.Rename(tmpFilename, filename)
+}
+
+func copyParts(manifest *Manifest, partFilenames []string, writer io.Writer) (int64, error) {
+	var total int64
+
+	for i, partFilename := range partFilenames {
+		part, err := os.Open(partFilename)
+		if err != nil {
+			return 0, err
+		}
+
+		n, err := io.Copy(writer, part)
+		part.Close()
+
+		if err != nil {
+			return 0, errors.WithMessagef(err, "Failed to copy part %v", i)
+		}
+
+		if n != manifest.Entries[i].Size {
+			return 0, errors.Errorf("Part %v size mismatch, expected = %v, actual = %v", i, manifest.Entries[i].Size, n)
+		}
+
+		total += n
+	}
+
+	return total, nil
+}
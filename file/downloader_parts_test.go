@@ -0,0 +1,65 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadParts(t *testing.T) {
+	data := createTestData(3*DefaultSegmentSize + 100)
+	manifest := NewPartsManifest()
+
+	var clients []*node.Client
+	for offset := 0; offset < len(data); offset += 2 * DefaultSegmentSize {
+		end := offset + 2*DefaultSegmentSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		mock, client := newMockNode(t, data[offset:end])
+		clients = append(clients, client)
+
+		manifest.Add(ManifestEntry{
+			Path: fmt.Sprintf("part-%v", len(clients)-1),
+			Root: mock.tree.Root(),
+			Size: int64(end - offset),
+		})
+	}
+
+	encoded, err := manifest.Encode()
+	assert.NoError(t, err)
+
+	mock, client := newMockNode(t, encoded)
+	root := mock.tree.Root().Hex()
+	clients = append([]*node.Client{client}, clients...)
+
+	// parts manifest downloaded as a regular file by default
+	filename := filepath.Join(t.TempDir(), "file")
+	assert.NoError(t, NewDownloader(clients...).Download(root, filename, false))
+	assertFileContent(t, filename, encoded)
+
+	// manifest kept if failed to download any part
+	filename = filepath.Join(t.TempDir(), "file")
+	err = NewDownloader(clients[:2]...).WithParts(true).Download(root, filename, false)
+	assert.Error(t, err)
+	assertFileContent(t, filename, encoded)
+	assertFileContent(t, filename+".part0", data[:2*DefaultSegmentSize])
+
+	// resume to download the rest parts
+	assert.NoError(t, NewDownloader(clients...).WithParts(true).Download(root, filename, false))
+	assertFileContent(t, filename, data)
+
+	_, err = os.Stat(filename + ".part0")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func assertFileContent(t *testing.T, filename string, expected []byte) {
+	content, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, content)
+}
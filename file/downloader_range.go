@@ -0,0 +1,228 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/common/parallel"
+	"github.com/Ionian-Web3-Storage/ionian-client/file/encryption"
+	"github.com/Ionian-Web3-Storage/ionian-client/node"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrRangeNotSupported is returned when download a range of encrypted file or large file split
+// into parts, in which case the range of plaintext could not be mapped to the stored data.
+var ErrRangeNotSupported = errors.New("range download not supported for encrypted or parts split file")
+
+// DownloadRange downloads length bytes of file from the specified offset, and writes data into
+// writer. Only chunks that cover the range will be downloaded from storage nodes. If proof
+// required, segments that cover the range will be downloaded along with merkle proofs.
+//
+// If length is negative, downloads data from offset to the end of file. Note, ErrRangeNotSupported
+// returned for encrypted or parts split file.
+func (downloader *Downloader) DownloadRange(ctx context.Context, root string, offset, length int64, writer io.Writer, proof bool) error {
+	hash := common.HexToHash(root)
+
+	info, clients, err := downloader.queryFile(ctx, hash)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to query file info")
+	}
+
+	size := int64(info.Tx.Size)
+	if length < 0 {
+		length = size - offset
+	}
+
+	if offset < 0 || length < 0 || offset+length > size {
+		return errors.Errorf("Range out of bound, offset = %v, length = %v, size = %v", offset, length, size)
+	}
+
+	pool := downloader.nodePool(clients)
+	routines := numRoutines(len(clients), downloader.perNode)
+
+	if err = downloader.checkRangeSupported(ctx, pool, routines, hash, size, proof); err != nil {
+		return err
+	}
+
+	if length == 0 {
+		return nil
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"offset": offset,
+		"length": length,
+	}).Info("Begin to download file range")
+
+	if err = downloader.downloadRange(ctx, pool, routines, hash, size, offset, length, writer, proof); err != nil {
+		return errors.WithMessage(err, "Failed to download file range")
+	}
+
+	logrus.Info("Completed to download file range")
+
+	return nil
+}
+
+// checkRangeSupported downloads the beginning of file to check whether the file is encrypted or
+// a parts manifest, which is not supported to download by range.
+func (downloader *Downloader) checkRangeSupported(ctx context.Context, pool *nodePool, routines int, root common.Hash, size int64, proof bool) error {
+	return checkRangeSupported(size, func(offset, length int64) ([]byte, error) {
+		var buf bytes.Buffer
+		err := downloader.downloadRange(ctx, pool, routines, root, size, offset, length, &buf, proof)
+		return buf.Bytes(), err
+	})
+}
+
+// checkRangeSupported reads the beginning of file with the specified read function, and returns
+// ErrRangeNotSupported if the file is encrypted or a parts manifest.
+func checkRangeSupported(size int64, read func(offset, length int64) ([]byte, error)) error {
+	length := int64(encryption.HeaderSize)
+	if length > size {
+		length = size
+	}
+
+	head, err := read(0, length)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to read file header")
+	}
+
+	if _, err = encryption.DecodeHeader(head); err == nil {
+		return ErrRangeNotSupported
+	}
+
+	if !IsPartsManifest(head) || size > maxPartsManifestSize {
+		return nil
+	}
+
+	data, err := read(0, size)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to read manifest")
+	}
+
+	if _, err = DecodeManifest(data); err == nil {
+		return ErrRangeNotSupported
+	}
+
+	return nil
+}
+
+// downloadRange downloads the segments that cover the specified range in parallel.
+func (downloader *Downloader) downloadRange(ctx context.Context, pool *nodePool, routines int, root common.Hash, size, offset, length int64, writer io.Writer, proof bool) error {
+	rd := newRangeDownloader(pool, root, size, offset, length, writer, proof)
+	rd.bandwidth = downloader.bandwidth
+
+	bufSize := routines * 2
+	if bufSize < minBufSize {
+		bufSize = minBufSize
+	}
+
+	return parallel.SerialContext(ctx, rd, int(rd.numSegments), routines, bufSize)
+}
+
+// rangeDownloader downloads segments that cover a range of file in parallel, and writes data in order.
+type rangeDownloader struct {
+	pool   *nodePool
+	root   common.Hash
+	writer io.Writer
+
+	withProof bool
+
+	offset    int64  // range offset in bytes
+	end       int64  // range end in bytes, exclusive
+	numChunks uint64 // number of chunks in file
+
+	segmentOffset uint64 // first segment that covers the range
+	numSegments   uint64 // number of segments that cover the range
+
+	bandwidth *bandwidthLimiter
+}
+
+func newRangeDownloader(pool *nodePool, root common.Hash, size, offset, length int64, writer io.Writer, withProof bool) *rangeDownloader {
+	end := offset + length
+	segmentOffset := uint64(offset / DefaultSegmentSize)
+
+	return &rangeDownloader{
+		pool:   pool,
+		root:   root,
+		writer: writer,
+
+		withProof: withProof,
+
+		offset:    offset,
+		end:       end,
+		numChunks: numSplits(size, DefaultChunkSize),
+
+		segmentOffset: segmentOffset,
+		numSegments:   numSplits(end, DefaultSegmentSize) - segmentOffset,
+	}
+}
+
+// ParallelDoContext implements the parallel.ContextInterface interface.
+func (downloader *rangeDownloader) ParallelDoContext(ctx context.Context, routine, task int) (interface{}, error) {
+	segmentIndex := downloader.segmentOffset + uint64(task)
+
+	// chunks of segment
+	startIndex := segmentIndex * DefaultSegmentMaxChunks
+	endIndex := startIndex + DefaultSegmentMaxChunks
+	if endIndex > downloader.numChunks {
+		endIndex = downloader.numChunks
+	}
+
+	// only download chunks that cover the range, unless proof required
+	if !downloader.withProof {
+		if rangeStart := uint64(downloader.offset / DefaultChunkSize); startIndex < rangeStart {
+			startIndex = rangeStart
+		}
+
+		if rangeEnd := numSplits(downloader.end, DefaultChunkSize); endIndex > rangeEnd {
+			endIndex = rangeEnd
+		}
+	}
+
+	if err := downloader.bandwidth.wait(ctx, int(endIndex-startIndex)*DefaultChunkSize); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+
+	err := downloader.pool.call(ctx, routine, func(client *node.Client) (err error) {
+		if downloader.withProof {
+			data, _, err = downloadSegmentWithProof(ctx, client, downloader.root, downloader.numChunks, startIndex, endIndex)
+		} else {
+			data, err = client.Ionian().DownloadSegmentContext(ctx, downloader.root, startIndex, endIndex)
+		}
+
+		if err == nil && uint64(len(data)) != (endIndex-startIndex)*DefaultChunkSize {
+			err = errors.Errorf("Downloaded data length mismatch, expected = %v, actual = %v", (endIndex-startIndex)*DefaultChunkSize, len(data))
+		}
+
+		return err
+	})
+
+	if err != nil {
+		return nil, errors.WithMessagef(err, "Failed to download segment %v", segmentIndex)
+	}
+
+	// trim to the exact range
+	dataOffset := int64(startIndex * DefaultChunkSize)
+
+	from := downloader.offset - dataOffset
+	if from < 0 {
+		from = 0
+	}
+
+	to := downloader.end - dataOffset
+	if to > int64(len(data)) {
+		to = int64(len(data))
+	}
+
+	return data[from:to], nil
+}
+
+// ParallelCollect implements the parallel.ContextInterface interface.
+func (downloader *rangeDownloader) ParallelCollect(result *parallel.Result) error {
+	_, err := downloader.writer.Write(result.Value.([]byte))
+	return err
+}
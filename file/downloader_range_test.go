@@ -0,0 +1,86 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/file/encryption"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadRange(t *testing.T) {
+	data := createTestData(3*DefaultSegmentSize + 1000)
+
+	mock, client1 := newMockNode(t, data)
+	_, client2 := newMockNode(t, data)
+	root := mock.tree.Root().Hex()
+
+	downloader := NewDownloader(client1, client2)
+
+	ranges := [][2]int64{
+		{0, 1},
+		{0, 100},
+		{100, DefaultChunkSize},
+		{DefaultSegmentSize - 10, 20},
+		{1000, 2*DefaultSegmentSize + 3},
+		{int64(len(data)) - 300, 300},
+		{0, int64(len(data))},
+	}
+
+	for _, proof := range []bool{false, true} {
+		for _, r := range ranges {
+			var buf bytes.Buffer
+			err := downloader.DownloadRange(context.Background(), root, r[0], r[1], &buf, proof)
+			assert.NoError(t, err)
+			assert.Equal(t, data[r[0]:r[0]+r[1]], buf.Bytes(), "offset = %v, length = %v, proof = %v", r[0], r[1], proof)
+		}
+	}
+
+	// to the end of file
+	var tail bytes.Buffer
+	assert.NoError(t, downloader.DownloadRange(context.Background(), root, 100, -1, &tail, false))
+	assert.Equal(t, data[100:], tail.Bytes())
+
+	// out of bound
+	var buf bytes.Buffer
+	assert.Error(t, downloader.DownloadRange(context.Background(), root, int64(len(data))-10, 11, &buf, false))
+	assert.Error(t, downloader.DownloadRange(context.Background(), root, -1, 10, &buf, false))
+}
+
+func TestDownloadRangeSingleNode(t *testing.T) {
+	data := createTestData(1000)
+
+	mock, client := newMockNode(t, data)
+
+	var buf bytes.Buffer
+	err := NewDownloader(client).DownloadRange(context.Background(), mock.tree.Root().Hex(), 10, 500, &buf, true)
+	assert.NoError(t, err)
+	assert.Equal(t, data[10:510], buf.Bytes())
+}
+
+func TestDownloadRangeNotSupported(t *testing.T) {
+	key, err := encryption.NewRawKey(make([]byte, encryption.KeySize))
+	assert.NoError(t, err)
+
+	plain := createTestData(1000)
+	reader, err := encryption.NewReader(key, bytes.NewReader(plain), int64(len(plain)))
+	assert.NoError(t, err)
+
+	encrypted, err := io.ReadAll(io.NewSectionReader(reader, 0, reader.Size()))
+	assert.NoError(t, err)
+
+	manifest := NewPartsManifest()
+	manifest.Add(ManifestEntry{Path: "part-0", Size: 1000})
+	parts, err := manifest.Encode()
+	assert.NoError(t, err)
+
+	for _, data := range [][]byte{encrypted, parts} {
+		mock, client := newMockNode(t, data)
+
+		var buf bytes.Buffer
+		err := NewDownloader(client).DownloadRange(context.Background(), mock.tree.Root().Hex(), 100, 10, &buf, false)
+		assert.ErrorIs(t, err, ErrRangeNotSupported)
+	}
+}
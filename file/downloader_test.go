@@ -0,0 +1,51 @@
+package file
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/file/encryption"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadDecryptRetry(t *testing.T) {
+	key, err := encryption.NewPassphraseKey("passphrase")
+	assert.NoError(t, err)
+
+	data := createTestData(DefaultSegmentSize + 100)
+	reader, err := encryption.NewReader(key, bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+
+	encrypted, err := io.ReadAll(io.NewSectionReader(reader, 0, reader.Size()))
+	assert.NoError(t, err)
+
+	mock, client := newMockNode(t, encrypted)
+	root := mock.tree.Root().Hex()
+
+	// encrypted data kept if failed to decrypt
+	wrongKey, err := encryption.NewPassphraseKey("wrong")
+	assert.NoError(t, err)
+
+	filename := filepath.Join(t.TempDir(), "file")
+	assert.Error(t, NewDownloader(client).WithDecryption(wrongKey).Download(root, filename, false))
+
+	_, err = os.Stat(filename)
+	assert.True(t, os.IsNotExist(err))
+	assertFileContent(t, filename+encryptedFileSuffix, encrypted)
+
+	// retry to decrypt without downloading again
+	downloads := mock.downloads
+	assert.NoError(t, NewDownloader(client).WithDecryption(key).Download(root, filename, false))
+	assert.Equal(t, downloads, mock.downloads)
+	assertFileContent(t, filename, data)
+
+	_, err = os.Stat(filename + encryptedFileSuffix)
+	assert.True(t, os.IsNotExist(err))
+
+	// decrypted file already exists
+	err = NewDownloader(client).WithDecryption(key).Download(root, filename, false)
+	assert.ErrorIs(t, err, ErrFileAlreadyExists)
+}
@@ -0,0 +1,357 @@
+// Package encryption provides client side encryption for files stored on Ionian network.
+//
+// Data is encrypted with AES-256-GCM over fixed-size blocks, and each encrypted block, along
+// with the authentication tag, is aligned to a segment. The first block is prefixed with a
+// header that records the cipher parameters, and each block is authenticated with the block
+// index and header, so that blocks could not be reordered, truncated or tampered.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// BlockSize is the size of encrypted block, which is the same as file segment size.
+const BlockSize = 256 * 1024
+
+const tagSize = 16
+
+var ErrNotEncrypted = errors.New("Data not encrypted")
+
+// layout describes how plaintext is split into encrypted blocks.
+type layout struct {
+	header    *Header
+	numBlocks int64
+}
+
+func newLayout(header *Header) layout {
+	l := layout{header: header, numBlocks: 1}
+
+	firstCap := l.blockCap(0)
+	if plainSize := int64(header.PlainSize); plainSize > firstCap {
+		cap := l.blockCap(1)
+		l.numBlocks += (plainSize - firstCap + cap - 1) / cap
+	}
+
+	return l
+}
+
+// blockCap returns the plaintext capacity of the specified block.
+func (l layout) blockCap(index int64) int64 {
+	if index == 0 {
+		return int64(l.header.BlockSize) - HeaderSize - tagSize
+	}
+
+	return int64(l.header.BlockSize) - tagSize
+}
+
+// plainRange returns the offset and length of plaintext in the specified block.
+func (l layout) plainRange(index int64) (int64, int64) {
+	var offset int64
+	if index > 0 {
+		offset = l.blockCap(0) + (index-1)*l.blockCap(1)
+	}
+
+	length := int64(l.header.PlainSize) - offset
+	if cap := l.blockCap(index); length > cap {
+		length = cap
+	}
+
+	return offset, length
+}
+
+// cipherSize returns the size of the specified encrypted block, including header if any.
+func (l layout) cipherSize(index int64) int64 {
+	_, length := l.plainRange(index)
+
+	if index == 0 {
+		return HeaderSize + length + tagSize
+	}
+
+	return length + tagSize
+}
+
+// size returns the total size of encrypted data.
+func (l layout) size() int64 {
+	last := l.numBlocks - 1
+	return last*int64(l.header.BlockSize) + l.cipherSize(last)
+}
+
+// EncryptedSize returns the size of encrypted data for the specified plaintext size.
+func EncryptedSize(plainSize int64) int64 {
+	return newLayout(&Header{BlockSize: BlockSize, PlainSize: uint64(plainSize)}).size()
+}
+
+// blockCipher encrypts or decrypts blocks with the derived file key.
+type blockCipher struct {
+	layout
+	aead cipher.AEAD
+	ad   []byte // encoded header as additional data
+}
+
+func newBlockCipher(key *Key, header *Header) (*blockCipher, error) {
+	fileKey, err := key.deriveFileKey(header)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(fileKey)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to create AES cipher")
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to create GCM cipher")
+	}
+
+	return &blockCipher{
+		layout: newLayout(header),
+		aead:   aead,
+		ad:     header.Encode(),
+	}, nil
+}
+
+// nonce returns the nonce of the specified block, which marks the last block to detect truncation.
+func (c *blockCipher) nonce(index int64) []byte {
+	nonce := make([]byte, c.aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce, uint64(index))
+
+	if index == c.numBlocks-1 {
+		nonce[len(nonce)-1] = 1
+	}
+
+	return nonce
+}
+
+func (c *blockCipher) seal(index int64, plaintext []byte) []byte {
+	var dst []byte
+	if index == 0 {
+		dst = append(dst, c.ad...)
+	}
+
+	return c.aead.Seal(dst, c.nonce(index), plaintext, c.ad)
+}
+
+func (c *blockCipher) open(index int64, data []byte) ([]byte, error) {
+	if index == 0 {
+		data = data[HeaderSize:]
+	}
+
+	plaintext, err := c.aead.Open(nil, c.nonce(index), data, c.ad)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "Failed to decrypt block %v", index)
+	}
+
+	return plaintext, nil
+}
+
+// Reader encrypts data from the underlying reader on demand, and implements io.ReaderAt.
+// Note, encrypted data is deterministic for the same Reader, so it is safe to read the
+// same range multiple times, e.g. to calculate merkle tree and then upload segments.
+type Reader struct {
+	*blockCipher
+	underlying io.ReaderAt
+
+	mu          sync.Mutex
+	cachedIndex int64
+	cached      []byte
+}
+
+// NewReader creates a Reader to encrypt data of given size with the specified key.
+func NewReader(key *Key, underlying io.ReaderAt, size int64) (*Reader, error) {
+	header := Header{
+		Version:   headerVersion,
+		Cipher:    CipherAES256GCM,
+		Kdf:       key.kdf(),
+		BlockSize: BlockSize,
+		PlainSize: uint64(size),
+	}
+
+	if header.Kdf == KdfScrypt {
+		header.ScryptN, header.ScryptR, header.ScryptP = DefaultScryptN, DefaultScryptR, DefaultScryptP
+	}
+
+	if _, err := rand.Read(header.Salt[:]); err != nil {
+		return nil, errors.WithMessage(err, "Failed to generate salt")
+	}
+
+	return newReader(key, &header, underlying)
+}
+
+// NewReaderWithHeader creates a Reader to encrypt data with the specified key and the header of
+// previous encryption, e.g. to resume upload with the same encrypted data. Note, the header must
+// only be reused for the unchanged data, otherwise, block nonces are reused for different data.
+func NewReaderWithHeader(key *Key, header *Header, underlying io.ReaderAt, size int64) (*Reader, error) {
+	if header.PlainSize != uint64(size) {
+		return nil, errors.Errorf("Data size mismatch with header, expected = %v, actual = %v", header.PlainSize, size)
+	}
+
+	if header.Kdf != key.kdf() {
+		return nil, errors.Errorf("Key mismatch with header kdf %v", header.Kdf)
+	}
+
+	return newReader(key, header, underlying)
+}
+
+func newReader(key *Key, header *Header, underlying io.ReaderAt) (*Reader, error) {
+	c, err := newBlockCipher(key, header)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{
+		blockCipher: c,
+		underlying:  underlying,
+		cachedIndex: -1,
+	}, nil
+}
+
+// Header returns the header of encrypted data.
+func (reader *Reader) Header() *Header {
+	return reader.header
+}
+
+// Size returns the size of encrypted data.
+func (reader *Reader) Size() int64 {
+	return reader.size()
+}
+
+// ReadAt implements the io.ReaderAt interface.
+func (reader *Reader) ReadAt(p []byte, off int64) (int, error) {
+	size := reader.Size()
+
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	n := 0
+	for n < len(p) && off < size {
+		index := off / int64(reader.header.BlockSize)
+
+		block, err := reader.encryptBlock(index)
+		if err != nil {
+			return n, err
+		}
+
+		copied := copy(p[n:], block[off-index*int64(reader.header.BlockSize):])
+		n += copied
+		off += int64(copied)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+func (reader *Reader) encryptBlock(index int64) ([]byte, error) {
+	reader.mu.Lock()
+	defer reader.mu.Unlock()
+
+	if reader.cachedIndex == index {
+		return reader.cached, nil
+	}
+
+	offset, length := reader.plainRange(index)
+
+	plaintext := make([]byte, length)
+	if n, err := reader.underlying.ReadAt(plaintext, offset); n < len(plaintext) {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return nil, errors.WithMessagef(err, "Failed to read block %v", index)
+	}
+
+	reader.cachedIndex = index
+	reader.cached = reader.seal(index, plaintext)
+
+	return reader.cached, nil
+}
+
+// Decrypt decrypts the encrypted data of given size with the specified key, and writes the
+// plaintext into writer.
+func Decrypt(key *Key, reader io.ReaderAt, size int64, writer io.Writer) error {
+	header, err := ReadHeader(reader)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to read header")
+	}
+
+	c, err := newBlockCipher(key, header)
+	if err != nil {
+		return err
+	}
+
+	if c.size() != size {
+		return errors.Errorf("Encrypted data size mismatch, expected = %v, actual = %v", c.size(), size)
+	}
+
+	buf := make([]byte, header.BlockSize)
+
+	for i := int64(0); i < c.numBlocks; i++ {
+		data := buf[:c.cipherSize(i)]
+		if _, err = reader.ReadAt(data, i*int64(header.BlockSize)); err != nil && err != io.EOF {
+			return errors.WithMessagef(err, "Failed to read block %v", i)
+		}
+
+		plaintext, err := c.open(i, data)
+		if err != nil {
+			return err
+		}
+
+		if _, err = writer.Write(plaintext); err != nil {
+			return errors.WithMessage(err, "Failed to write decrypted data")
+		}
+	}
+
+	return nil
+}
+
+// DecryptFile decrypts the specified file into the output file, which is written to a temp file at
+// first and renamed once decrypted, so that output file never contains data that failed to decrypt.
+// Note, the specified file is not removed, and output file will be overwritten if exists.
+func DecryptFile(key *Key, filename, output string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to open file")
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return errors.WithMessage(err, "Failed to stat file")
+	}
+
+	tmpFilename := output + ".decrypting"
+
+	tmpFile, err := os.OpenFile(tmpFilename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return errors.WithMessage(err, "Failed to create temp file")
+	}
+
+	err = Decrypt(key, file, info.Size(), tmpFile)
+
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(tmpFilename)
+		return err
+	}
+
+	if err = os.Rename(tmpFilename, output); err != nil {
+		return errors.WithMessage(err, "Failed to rename decrypted file")
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package encryption
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestData(size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(data)
+	return data
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	key, err := NewRawKey(createTestData(KeySize))
+	assert.NoError(t, err)
+
+	firstCap := BlockSize - HeaderSize - tagSize
+	sizes := []int{0, 1, firstCap, firstCap + 1, firstCap + BlockSize - tagSize, 3*BlockSize + 1000}
+
+	for _, size := range sizes {
+		data := createTestData(size)
+
+		reader, err := NewReader(key, bytes.NewReader(data), int64(size))
+		assert.NoError(t, err)
+		assert.Equal(t, EncryptedSize(int64(size)), reader.Size())
+
+		encrypted, err := io.ReadAll(io.NewSectionReader(reader, 0, reader.Size()))
+		assert.NoError(t, err)
+		assert.Equal(t, int(reader.Size()), len(encrypted))
+
+		// encrypted blocks aligned to segments
+		assert.Equal(t, reader.numBlocks, int64(len(encrypted)+BlockSize-1)/BlockSize)
+		if size == firstCap {
+			assert.Equal(t, BlockSize, len(encrypted))
+		}
+
+		var decrypted bytes.Buffer
+		assert.NoError(t, Decrypt(key, bytes.NewReader(encrypted), int64(len(encrypted)), &decrypted))
+		assert.Equal(t, data, append([]byte{}, decrypted.Bytes()...))
+	}
+}
+
+func TestReadAtDeterministic(t *testing.T) {
+	key, err := NewPassphraseKey("passphrase")
+	assert.NoError(t, err)
+
+	data := createTestData(2*BlockSize + 100)
+
+	reader, err := NewReader(key, bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+
+	encrypted := make([]byte, reader.Size())
+	n, err := reader.ReadAt(encrypted, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, len(encrypted), n)
+
+	// read across blocks at unaligned offset
+	buf := make([]byte, BlockSize)
+	n, err = reader.ReadAt(buf, 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, encrypted[1000:1000+BlockSize], buf[:n])
+
+	// read beyond the end
+	n, err = reader.ReadAt(buf, reader.Size()-10)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, encrypted[len(encrypted)-10:], buf[:n])
+}
+
+func TestDecryptWithWrongKey(t *testing.T) {
+	key, _ := NewPassphraseKey("passphrase")
+	data := createTestData(1000)
+
+	reader, err := NewReader(key, bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+
+	encrypted := make([]byte, reader.Size())
+	_, err = reader.ReadAt(encrypted, 0)
+	assert.NoError(t, err)
+
+	wrongKey, _ := NewPassphraseKey("wrong")
+	assert.Error(t, Decrypt(wrongKey, bytes.NewReader(encrypted), int64(len(encrypted)), io.Discard))
+
+	rawKey, _ := NewRawKey(createTestData(KeySize))
+	assert.Error(t, Decrypt(rawKey, bytes.NewReader(encrypted), int64(len(encrypted)), io.Discard))
+
+	// tampered data
+	encrypted[HeaderSize+1] ^= 1
+	assert.Error(t, Decrypt(key, bytes.NewReader(encrypted), int64(len(encrypted)), io.Discard))
+
+	// not encrypted
+	assert.ErrorIs(t, Decrypt(key, bytes.NewReader(data[:10]), 10, io.Discard), ErrNotEncrypted)
+}
+
+func TestDecodeHeaderParameters(t *testing.T) {
+	valid := Header{
+		Version:   headerVersion,
+		Cipher:    CipherAES256GCM,
+		Kdf:       KdfScrypt,
+		BlockSize: BlockSize,
+		ScryptN:   DefaultScryptN,
+		ScryptR:   DefaultScryptR,
+		ScryptP:   DefaultScryptP,
+		PlainSize: 100,
+	}
+
+	header, err := DecodeHeader(valid.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, valid, *header)
+
+	tampers := []func(h *Header){
+		func(h *Header) { h.BlockSize = 1 << 31 },
+		func(h *Header) { h.BlockSize = BlockSize / 2 },
+		func(h *Header) { h.ScryptN = 1 << 30 },
+		func(h *Header) { h.ScryptR = 1 << 20 },
+		func(h *Header) { h.ScryptP = 100 },
+		func(h *Header) { h.Kdf = KdfNone },
+		func(h *Header) { h.Kdf = 10 },
+	}
+
+	for _, tamper := range tampers {
+		header := valid
+		tamper(&header)
+
+		_, err := DecodeHeader(header.Encode())
+		assert.Error(t, err)
+	}
+}
+
+func TestNewReaderWithHeader(t *testing.T) {
+	key, _ := NewPassphraseKey("passphrase")
+	data := createTestData(BlockSize + 100)
+
+	reader, err := NewReader(key, bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+
+	encrypted, err := io.ReadAll(io.NewSectionReader(reader, 0, reader.Size()))
+	assert.NoError(t, err)
+
+	header, err := DecodeHeader(reader.Header().Encode())
+	assert.NoError(t, err)
+
+	resumed, err := NewReaderWithHeader(key, header, bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+
+	reencrypted, err := io.ReadAll(io.NewSectionReader(resumed, 0, resumed.Size()))
+	assert.NoError(t, err)
+	assert.Equal(t, encrypted, reencrypted)
+
+	// size or key mismatch
+	_, err = NewReaderWithHeader(key, header, bytes.NewReader(data), int64(len(data)-1))
+	assert.Error(t, err)
+
+	rawKey, _ := NewRawKey(createTestData(KeySize))
+	_, err = NewReaderWithHeader(rawKey, header, bytes.NewReader(data), int64(len(data)))
+	assert.Error(t, err)
+}
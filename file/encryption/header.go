@@ -0,0 +1,122 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Supported cipher and key derivation functions.
+const (
+	CipherAES256GCM uint8 = 1
+
+	KdfNone   uint8 = 0 // raw key
+	KdfScrypt uint8 = 1 // passphrase
+)
+
+const (
+	headerMagic   = "IONCRYPT"
+	headerVersion = 1
+
+	// HeaderSize is the size of encoded header at the beginning of encrypted data.
+	HeaderSize = 68
+)
+
+// Header records the cipher parameters of encrypted data.
+type Header struct {
+	Version   uint8
+	Cipher    uint8
+	Kdf       uint8
+	BlockSize uint32
+
+	// scrypt parameters, only available when Kdf is KdfScrypt
+	ScryptN uint32
+	ScryptR uint32
+	ScryptP uint32
+
+	Salt      [32]byte
+	PlainSize uint64
+}
+
+// Encode encodes header into bytes of HeaderSize.
+func (header *Header) Encode() []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString(headerMagic)
+	buf.Write([]byte{header.Version, header.Cipher, header.Kdf, 0})
+	binary.Write(&buf, binary.BigEndian, header.BlockSize)
+	binary.Write(&buf, binary.BigEndian, header.ScryptN)
+	binary.Write(&buf, binary.BigEndian, header.ScryptR)
+	binary.Write(&buf, binary.BigEndian, header.ScryptP)
+	buf.Write(header.Salt[:])
+	binary.Write(&buf, binary.BigEndian, header.PlainSize)
+
+	return buf.Bytes()
+}
+
+// ReadHeader reads header at the beginning of the encrypted data.
+func ReadHeader(reader io.ReaderAt) (*Header, error) {
+	buf := make([]byte, HeaderSize)
+	if n, err := reader.ReadAt(buf, 0); n < HeaderSize {
+		if err == nil || err == io.EOF {
+			err = ErrNotEncrypted
+		}
+
+		return nil, err
+	}
+
+	return DecodeHeader(buf)
+}
+
+// DecodeHeader decodes header from the specified bytes.
+func DecodeHeader(data []byte) (*Header, error) {
+	if len(data) < HeaderSize || string(data[:len(headerMagic)]) != headerMagic {
+		return nil, ErrNotEncrypted
+	}
+
+	var header Header
+
+	reader := bytes.NewReader(data[len(headerMagic):HeaderSize])
+
+	var flags [4]byte
+	reader.Read(flags[:])
+	header.Version, header.Cipher, header.Kdf = flags[0], flags[1], flags[2]
+
+	binary.Read(reader, binary.BigEndian, &header.BlockSize)
+	binary.Read(reader, binary.BigEndian, &header.ScryptN)
+	binary.Read(reader, binary.BigEndian, &header.ScryptR)
+	binary.Read(reader, binary.BigEndian, &header.ScryptP)
+	reader.Read(header.Salt[:])
+	binary.Read(reader, binary.BigEndian, &header.PlainSize)
+
+	if header.Version != headerVersion {
+		return nil, errors.Errorf("Unsupported version %v", header.Version)
+	}
+
+	if header.Cipher != CipherAES256GCM {
+		return nil, errors.Errorf("Unsupported cipher %v", header.Cipher)
+	}
+
+	// header is not authenticated until decrypted, so only accept parameters that
+	// writer produces to avoid huge memory allocation or expensive key derivation.
+	if header.BlockSize != BlockSize {
+		return nil, errors.Errorf("Invalid block size %v", header.BlockSize)
+	}
+
+	switch header.Kdf {
+	case KdfNone:
+		if header.ScryptN != 0 || header.ScryptR != 0 || header.ScryptP != 0 {
+			return nil, errors.New("Unexpected scrypt parameters for raw key")
+		}
+	case KdfScrypt:
+		if header.ScryptN != DefaultScryptN || header.ScryptR != DefaultScryptR || header.ScryptP != DefaultScryptP {
+			return nil, errors.Errorf("Invalid scrypt parameters, N = %v, r = %v, p = %v", header.ScryptN, header.ScryptR, header.ScryptP)
+		}
+	default:
+		return nil, errors.Errorf("Unsupported kdf %v", header.Kdf)
+	}
+
+	return &header, nil
+}
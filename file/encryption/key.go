@@ -0,0 +1,108 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+// KeySize is the size of raw key for AES-256.
+const KeySize = 32
+
+// Default scrypt parameters to derive key from passphrase.
+const (
+	DefaultScryptN = 1 << 15
+	DefaultScryptR = 8
+	DefaultScryptP = 1
+)
+
+// Key is the secret to encrypt or decrypt files, which is either a raw key or a passphrase.
+// Note, a different file key is derived for each file along with a random salt.
+type Key struct {
+	raw        []byte
+	passphrase []byte
+}
+
+// NewRawKey creates a key with the specified 32 bytes raw key.
+func NewRawKey(key []byte) (*Key, error) {
+	if len(key) != KeySize {
+		return nil, errors.Errorf("Invalid key size %v, expected %v", len(key), KeySize)
+	}
+
+	return &Key{raw: key}, nil
+}
+
+// NewPassphraseKey creates a key with the specified passphrase, which is used to derive key
+// via scrypt.
+func NewPassphraseKey(passphrase string) (*Key, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("Passphrase is empty")
+	}
+
+	return &Key{passphrase: []byte(passphrase)}, nil
+}
+
+// LoadKeyFile loads raw key from the specified file, which contains either 32 bytes binary
+// data or 64 hex characters.
+func LoadKeyFile(filename string) (*Key, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to read key file")
+	}
+
+	if len(content) == KeySize {
+		return NewRawKey(content)
+	}
+
+	trimmed := bytes.TrimPrefix(bytes.TrimSpace(content), []byte("0x"))
+	key := make([]byte, hex.DecodedLen(len(trimmed)))
+	if _, err = hex.Decode(key, trimmed); err != nil {
+		return nil, errors.WithMessage(err, "Failed to decode hex key")
+	}
+
+	return NewRawKey(key)
+}
+
+func (key *Key) kdf() uint8 {
+	if len(key.passphrase) > 0 {
+		return KdfScrypt
+	}
+
+	return KdfNone
+}
+
+// deriveFileKey derives the file key with parameters in header.
+func (key *Key) deriveFileKey(header *Header) ([]byte, error) {
+	var master []byte
+
+	switch header.Kdf {
+	case KdfNone:
+		if len(key.raw) == 0 {
+			return nil, errors.New("Raw key required to decrypt")
+		}
+
+		master = key.raw
+	case KdfScrypt:
+		if len(key.passphrase) == 0 {
+			return nil, errors.New("Passphrase required to decrypt")
+		}
+
+		var err error
+		master, err = scrypt.Key(key.passphrase, header.Salt[:], int(header.ScryptN), int(header.ScryptR), int(header.ScryptP), KeySize)
+		if err != nil {
+			return nil, errors.WithMessage(err, "Failed to derive key from passphrase")
+		}
+	default:
+		return nil, errors.Errorf("Unsupported kdf %v", header.Kdf)
+	}
+
+	mac := hmac.New(sha256.New, master)
+	mac.Write(header.Salt[:])
+
+	return mac.Sum(nil), nil
+}
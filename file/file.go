@@ -1,12 +1,17 @@
 package file
 
 import (
-	"errors"
+	"io"
 	"os"
+	"runtime"
+	"time"
 
+	"github.com/Ionian-Web3-Storage/ionian-client/common/parallel"
 	"github.com/Ionian-Web3-Storage/ionian-client/file/merkle"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -31,11 +36,24 @@ var (
 
 	// ErrFileEmpty is returned when empty file opened.
 	ErrFileEmpty = errors.New("file is empty")
+
+	// ErrFileAlreadyExists is returned when file already exists on Ionian network or local disk.
+	ErrFileAlreadyExists = errors.New("file already exists")
+
+	// ErrFileTooLarge is returned when file size exceeds MaxSubmissionSize in a single submission.
+	ErrFileTooLarge = errors.New("file too large")
 )
 
 type File struct {
 	os.FileInfo
-	underlying *os.File
+	underlying io.ReaderAt
+	closer     func() error
+
+	hashWorkers int         // number of routines to calculate segment roots in parallel
+	path        string      // path of file opened on disk, which is required to cache segment roots
+	diskInfo    os.FileInfo // info of file opened on disk to validate cached segment roots
+	offset      int64       // offset of data in file opened on disk, e.g. part of large file
+	rootCache   *RootCache  // cache of segment roots, nil means no cache
 }
 
 func Exists(name string) (bool, error) {
@@ -75,11 +93,50 @@ func Open(name string) (*File, error) {
 	return &File{
 		FileInfo:   info,
 		underlying: file,
+		closer:     file.Close,
+		path:       name,
+		diskInfo:   info,
+	}, nil
+}
+
+// OpenReaderAt opens data from the specified reader of given size, e.g. in-memory buffer,
+// so as to upload without writing to a file on disk at first.
+func OpenReaderAt(reader io.ReaderAt, size int64) (*File, error) {
+	if size == 0 {
+		return nil, ErrFileEmpty
+	}
+
+	return &File{
+		FileInfo:   &dataInfo{size: size},
+		underlying: reader,
+	}, nil
+}
+
+// Part returns the data of specified size from offset as a file, e.g. part of large file to upload, which
+// shares the underlying reader and options with file. Segment roots of part are cached along with the
+// offset in file opened on disk.
+func (file *File) Part(offset, size int64) (*File, error) {
+	if offset < 0 || size <= 0 || offset+size > file.Size() {
+		return nil, errors.Errorf("Part out of bound, offset = %v, size = %v, file size = %v", offset, size, file.Size())
+	}
+
+	return &File{
+		FileInfo:    &dataInfo{size: size},
+		underlying:  io.NewSectionReader(file.underlying, offset, size),
+		hashWorkers: file.hashWorkers,
+		path:        file.path,
+		diskInfo:    file.diskInfo,
+		offset:      file.offset + offset,
+		rootCache:   file.rootCache,
 	}, nil
 }
 
 func (file *File) Close() error {
-	return file.underlying.Close()
+	if file.closer == nil {
+		return nil
+	}
+
+	return file.closer()
 }
 
 func (file *File) NumChunks() uint64 {
@@ -95,34 +152,144 @@ func (file *File) Iterate(flowPadding bool) *Iterator {
 	return NewSegmentIterator(file.underlying, file.Size(), 0, flowPadding)
 }
 
+// MerkleTree builds the merkle tree of file, which is required to generate merkle proofs of segments.
+// Use MerkleRoot instead if only the merkle root required.
 func (file *File) MerkleTree() (*merkle.Tree, error) {
-	iter := file.Iterate(true)
 	var builder merkle.TreeBuilder
 
-	for {
-		ok, err := iter.Next()
-		if err != nil {
-			return nil, err
-		}
+	if err := file.iterateSegmentRoots(builder.AppendHash); err != nil {
+		return nil, err
+	}
 
-		if !ok {
-			break
-		}
+	return builder.Build(), nil
+}
 
-		segRoot := segmentRoot(iter.Current())
+// MerkleRoot calculates the merkle root of file in streaming way, which requires O(log n) memory only,
+// and segment roots are streamed from or into the root cache if enabled.
+func (file *File) MerkleRoot() (common.Hash, error) {
+	var builder merkle.StreamingTreeBuilder
 
-		builder.AppendHash(segRoot)
+	if err := file.iterateSegmentRoots(builder.AppendHash); err != nil {
+		return common.Hash{}, err
 	}
 
-	return builder.Build(), nil
+	return builder.Root(), nil
+}
+
+// WithHashWorkers sets the number of routines to calculate segment roots in parallel when building
+// merkle tree, default runtime.NumCPU().
+func (file *File) WithHashWorkers(workers int) *File {
+	file.hashWorkers = workers
+	return file
 }
 
+// WithRootCache sets the cache of segment roots, so that merkle root need not to be calculated again
+// if file unchanged. Note, only file opened on disk will be cached.
+func (file *File) WithRootCache(cache *RootCache) *File {
+	file.rootCache = cache
+	return file
+}
+
+// iterateSegmentRoots iterates the root of flow padded segments in order, which are loaded from root cache
+// if available, or calculated and then cached.
+func (file *File) iterateSegmentRoots(fn func(segRoot common.Hash)) error {
+	if file.rootCache == nil || file.path == "" {
+		return file.calculateSegmentRoots(fn)
+	}
+
+	key := rootCacheKey{file.path, file.diskInfo, file.offset, file.Size()}
+
+	if ok, err := file.rootCache.iterate(key, fn); ok {
+		logrus.WithField("file", file.path).WithField("offset", file.offset).Debug("Segment roots loaded from cache")
+		return err
+	}
+
+	writer, err := file.rootCache.create(key)
+	if err != nil {
+		logrus.WithError(err).WithField("file", file.path).Warn("Failed to create root cache entry")
+		return file.calculateSegmentRoots(fn)
+	}
+
+	if err = file.calculateSegmentRoots(func(segRoot common.Hash) {
+		writer.append(segRoot)
+		fn(segRoot)
+	}); err != nil {
+		writer.abort()
+		return err
+	}
+
+	if err = writer.commit(); err != nil {
+		logrus.WithError(err).WithField("file", file.path).Warn("Failed to cache segment roots")
+	}
+
+	return nil
+}
+
+// calculateSegmentRoots calculates the root of flow padded segments in parallel, and collects in order.
+func (file *File) calculateSegmentRoots(fn func(segRoot common.Hash)) error {
+	workers := file.hashWorkers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	hasher := segmentHasher{
+		iters:   make([]*Iterator, workers),
+		collect: fn,
+	}
+
+	// each routine reads segments with a separate buffer
+	for i := range hasher.iters {
+		hasher.iters[i] = file.Iterate(true)
+	}
+
+	return parallel.Serial(&hasher, int(NumSegmentsFlowPadded(file.Size())), workers, workers*2)
+}
+
+// segmentHasher implements the parallel.Interface to calculate segment roots in parallel.
+type segmentHasher struct {
+	iters   []*Iterator // iterator of each routine
+	collect func(segRoot common.Hash)
+}
+
+func (hasher *segmentHasher) ParallelDo(routine, task int) (interface{}, error) {
+	iter := hasher.iters[routine]
+	iter.offset = int64(task) * DefaultSegmentSize
+
+	ok, err := iter.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	return segmentRoot(iter.Current()), nil
+}
+
+func (hasher *segmentHasher) ParallelCollect(result *parallel.Result) error {
+	hasher.collect(result.Value.(common.Hash))
+	return nil
+}
+
+// dataInfo implements the os.FileInfo interface for data not in a file.
+type dataInfo struct {
+	size int64
+}
+
+func (info *dataInfo) Name() string       { return "" }
+func (info *dataInfo) Size() int64        { return info.size }
+func (info *dataInfo) Mode() os.FileMode  { return 0 }
+func (info *dataInfo) ModTime() time.Time { return time.Time{} }
+func (info *dataInfo) IsDir() bool        { return false }
+func (info *dataInfo) Sys() interface{}   { return nil }
+
 func numSplits(total int64, unit int) uint64 {
 	return uint64((total-1)/int64(unit) + 1)
 }
 
 func segmentRoot(chunks []byte, emptyChunksPadded ...uint64) common.Hash {
-	var builder merkle.TreeBuilder
+	var builder merkle.StreamingTreeBuilder
 
 	// append chunks
 	for offset, dataLen := 0, len(chunks); offset < dataLen; offset += DefaultChunkSize {
@@ -137,9 +304,5 @@ func segmentRoot(chunks []byte, emptyChunksPadded ...uint64) common.Hash {
 		}
 	}
 
-	if tree := builder.Build(); tree != nil {
-		return tree.Root()
-	}
-
-	return common.Hash{}
+	return builder.Root()
 }
@@ -0,0 +1,109 @@
+package file
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestData(size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(data)
+	return data
+}
+
+func createTestFile(t *testing.T, data []byte) string {
+	tmpFile, err := os.CreateTemp(t.TempDir(), "ionian-client-test-*")
+	assert.NoError(t, err)
+	defer tmpFile.Close()
+
+	_, err = tmpFile.Write(data)
+	assert.NoError(t, err)
+
+	return tmpFile.Name()
+}
+
+func TestOpenReaderAt(t *testing.T) {
+	for _, size := range []int{1, 255, 256, 257, DefaultSegmentSize, DefaultSegmentSize*3 + 1000} {
+		data := createTestData(size)
+
+		filename := createTestFile(t, data)
+
+		file, err := Open(filename)
+		assert.NoError(t, err)
+		tree, err := file.MerkleTree()
+		assert.NoError(t, err)
+		assert.NoError(t, file.Close())
+
+		// open from in-memory buffer
+		file, err = OpenReaderAt(bytes.NewReader(data), int64(size))
+		assert.NoError(t, err)
+		tree2, err := file.MerkleTree()
+		assert.NoError(t, err)
+		assert.Equal(t, tree.Root(), tree2.Root())
+
+		// spool in memory
+		file, err = Spool(bytes.NewReader(data), int64(size))
+		assert.NoError(t, err)
+		tree3, err := file.MerkleTree()
+		assert.NoError(t, err)
+		assert.Equal(t, tree.Root(), tree3.Root())
+		assert.NoError(t, file.Close())
+
+		// spool to temp file
+		file, err = Spool(bytes.NewReader(data), int64(size/2+1))
+		assert.NoError(t, err)
+		tree4, err := file.MerkleTree()
+		assert.NoError(t, err)
+		assert.Equal(t, tree.Root(), tree4.Root())
+		assert.NoError(t, file.Close())
+	}
+}
+
+func TestOpenReaderAtShortData(t *testing.T) {
+	data := createTestData(1000)
+
+	file, err := OpenReaderAt(bytes.NewReader(data), 2000)
+	assert.NoError(t, err)
+
+	_, err = file.MerkleTree()
+	assert.Error(t, err)
+}
+
+func TestMerkleRoot(t *testing.T) {
+	for _, size := range []int{1, 257, DefaultSegmentSize, DefaultSegmentSize*5 + 1000} {
+		file, err := OpenReaderAt(bytes.NewReader(createTestData(size)), int64(size))
+		assert.NoError(t, err)
+
+		tree, err := file.MerkleTree()
+		assert.NoError(t, err)
+
+		root, err := file.MerkleRoot()
+		assert.NoError(t, err)
+		assert.Equal(t, tree.Root(), root)
+	}
+}
+
+func TestMerkleRootParallel(t *testing.T) {
+	size := DefaultSegmentSize*9 + 1000
+	data := createTestData(size)
+
+	file, err := OpenReaderAt(bytes.NewReader(data), int64(size))
+	assert.NoError(t, err)
+
+	expected, err := file.WithHashWorkers(1).MerkleRoot()
+	assert.NoError(t, err)
+
+	for _, workers := range []int{2, 3, 16, 64} {
+		root, err := file.WithHashWorkers(workers).MerkleRoot()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, root)
+
+		tree, err := file.MerkleTree()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, tree.Root())
+	}
+}
@@ -9,6 +9,10 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// MaxSubmissionSize is the maximum file size in bytes for a single flow submission. Larger file
+// should be split into multiple parts to upload, see UploadOption.PartSize for more details.
+const MaxSubmissionSize = int64(1) << 31
+
 type Flow struct {
 	file *File
 	tags []byte
@@ -19,7 +23,10 @@ func NewFlow(file *File, tags []byte) *Flow {
 }
 
 func (flow *Flow) CreateSubmission() (*contract.IonianSubmission, error) {
-	// TODO(kevin): limit file size, e.g., 2^31
+	if flow.file.Size() > MaxSubmissionSize {
+		return nil, ErrFileTooLarge
+	}
+
 	submission := contract.IonianSubmission{
 		Length: big.NewInt(flow.file.Size()),
 		Tags:   flow.tags,
@@ -0,0 +1,16 @@
+package file
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateSubmissionTooLarge(t *testing.T) {
+	file, err := OpenReaderAt(bytes.NewReader(nil), MaxSubmissionSize+1)
+	assert.NoError(t, err)
+
+	_, err = NewFlow(file, nil).CreateSubmission()
+	assert.Equal(t, ErrFileTooLarge, err)
+}
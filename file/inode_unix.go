@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package file
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileInode returns the inode of file, or 0 if unavailable.
+func fileInode(info os.FileInfo) uint64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Ino)
+	}
+
+	return 0
+}
@@ -0,0 +1,11 @@
+//go:build windows
+// +build windows
+
+package file
+
+import "os"
+
+// fileInode returns 0 on windows, where inode is unavailable in os.FileInfo.
+func fileInode(info os.FileInfo) uint64 {
+	return 0
+}
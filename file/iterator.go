@@ -2,13 +2,12 @@ package file
 
 import (
 	"io"
-	"os"
 
 	"github.com/pkg/errors"
 )
 
 type Iterator struct {
-	file       *os.File
+	reader     io.ReaderAt
 	buf        []byte // buffer to read data from file
 	bufSize    int    // actual data size in buffer
 	fileSize   int64
@@ -16,11 +15,11 @@ type Iterator struct {
 	offset     int64 // offset to read data
 }
 
-func NewSegmentIterator(file *os.File, fileSize int64, offset int64, flowPadding bool) *Iterator {
-	return NewIterator(file, fileSize, offset, DefaultSegmentSize, flowPadding)
+func NewSegmentIterator(reader io.ReaderAt, fileSize int64, offset int64, flowPadding bool) *Iterator {
+	return NewIterator(reader, fileSize, offset, DefaultSegmentSize, flowPadding)
 }
 
-func NewIterator(file *os.File, fileSize int64, offset int64, batch int64, flowPadding bool) *Iterator {
+func NewIterator(reader io.ReaderAt, fileSize int64, offset int64, batch int64, flowPadding bool) *Iterator {
 	if batch%DefaultChunkSize > 0 {
 		panic("batch size should align with chunk size")
 	}
@@ -37,7 +36,7 @@ func NewIterator(file *os.File, fileSize int64, offset int64, batch int64, flowP
 	}
 
 	return &Iterator{
-		file:       file,
+		reader:     reader,
 		buf:        buf,
 		offset:     offset,
 		fileSize:   fileSize,
@@ -66,24 +65,24 @@ func (it *Iterator) Next() (bool, error) {
 		return true, nil
 	}
 
-	n, err := it.file.ReadAt(it.buf, it.offset)
-	it.bufSize = n
-	it.offset += int64(n)
-
-	// not reach EOF
-	if n == expectedBufSize {
-		return true, nil
+	// only read data within file size, and pad zeros for the rest
+	readSize := expectedBufSize
+	if remaining := it.fileSize - it.offset; remaining < int64(readSize) {
+		readSize = int(remaining)
 	}
 
-	// unexpected IO error
-	if !errors.Is(err, io.EOF) {
+	n, err := it.reader.ReadAt(it.buf[:readSize], it.offset)
+	if n < readSize {
+		if err == nil || errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+
+		// unexpected IO error
 		return false, err
 	}
 
-	if n > expectedBufSize {
-		// should never happen
-		panic("load more data from file than expected")
-	}
+	it.bufSize = n
+	it.offset += int64(n)
 
 	it.paddingZeros(expectedBufSize - n)
 
@@ -0,0 +1,355 @@
+package file
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/file/encryption"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// JournalSuffix is the suffix of upload journal, which is stored next to the file to upload.
+const JournalSuffix = ".upload"
+
+// encryptionJournalSuffix is the suffix of journal that records the encryption header, which is
+// stored next to the upload journal.
+const encryptionJournalSuffix = ".encryption"
+
+// journalRecord is a single line in upload journal file. The first record is always the
+// header that contains file root and size, and the following records are appended when
+// transaction sent, transaction executed or segment uploaded.
+type journalRecord struct {
+	Root *common.Hash `json:"root,omitempty"`
+	Size *int64       `json:"size,omitempty"`
+
+	TxHash *common.Hash `json:"txHash,omitempty"`
+	TxSeq  *uint64      `json:"txSeq,omitempty"`
+
+	Segment *uint64 `json:"segment,omitempty"`
+
+	// encryption header and modification time of the plain file to encrypt
+	Encryption hexutil.Bytes `json:"encryption,omitempty"`
+	ModTime    *int64        `json:"modTime,omitempty"`
+	Inode      *uint64       `json:"inode,omitempty"`
+}
+
+// uploadJournal is an append-only journal to record the upload progress of file, so that
+// upload could be resumed after process crashed, without submitting log entry again or
+// uploading the acknowledged segments again.
+//
+// Note, all methods are nil-safe, in which case nothing will be recorded.
+type uploadJournal struct {
+	path string
+	file *os.File
+	mu   sync.Mutex
+
+	txHash *common.Hash
+	txSeq  *uint64
+	acked  map[uint64]bool
+}
+
+// openUploadJournal opens the journal for the specified file root and size. Journal will be
+// reset if it is corrupted or recorded for another file, e.g. file changed since last upload.
+func openUploadJournal(path string, root common.Hash, size int64) (*uploadJournal, error) {
+	journal := uploadJournal{
+		path:  path,
+		acked: make(map[uint64]bool),
+	}
+
+	records, complete, err := readJournalRecords(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to read journal")
+	}
+
+	if len(records) > 0 && (records[0].Root == nil || *records[0].Root != root || records[0].Size == nil || *records[0].Size != size) {
+		logrus.WithField("journal", path).Warn("Upload journal mismatch with file, reset it")
+		records, complete = nil, false
+	}
+
+	for _, r := range records {
+		if r.TxHash != nil {
+			journal.txHash = r.TxHash
+		}
+
+		if r.TxSeq != nil {
+			journal.txSeq = r.TxSeq
+		}
+
+		if r.Segment != nil {
+			journal.acked[*r.Segment] = true
+		}
+	}
+
+	// rewrite journal to drop the partially written or mismatched records
+	if !complete {
+		if len(records) == 0 {
+			records = []journalRecord{{Root: &root, Size: &size}}
+		}
+
+		if err = rewriteJournal(path, records); err != nil {
+			return nil, errors.WithMessage(err, "Failed to rewrite journal")
+		}
+	}
+
+	if journal.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0666); err != nil {
+		return nil, errors.WithMessage(err, "Failed to open journal")
+	}
+
+	if len(records) > 1 {
+		logrus.WithFields(logrus.Fields{
+			"journal": path,
+			"txHash":  journal.txHash,
+			"txSeq":   journal.txSeq,
+			"acked":   len(journal.acked),
+		}).Info("Upload journal loaded")
+	}
+
+	return &journal, nil
+}
+
+// newMemoryJournal creates a journal that is only kept in memory, e.g. to pass the transaction
+// hash between stages of batch upload.
+func newMemoryJournal() *uploadJournal {
+	return &uploadJournal{
+		acked: make(map[uint64]bool),
+	}
+}
+
+// readJournalRecords reads all records from the journal file, and returns false if the journal
+// does not exist or the last record is partially written, e.g. process crashed while writing.
+func readJournalRecords(path string) ([]journalRecord, bool, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	var records []journalRecord
+
+	lines := bytes.Split(content, []byte{'\n'})
+	for i, line := range lines {
+		// the last line should be empty if all records completely written
+		if i == len(lines)-1 {
+			return records, len(line) == 0, nil
+		}
+
+		var r journalRecord
+		if err = json.Unmarshal(line, &r); err != nil {
+			return records, false, nil
+		}
+
+		records = append(records, r)
+	}
+
+	return records, false, nil
+}
+
+// rewriteJournal writes records into a temp file and then replaces the journal atomically.
+func rewriteJournal(path string, records []journalRecord) error {
+	var buf bytes.Buffer
+
+	for _, r := range records {
+		encoded, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+
+		buf.Write(encoded)
+		buf.WriteByte('\n')
+	}
+
+	tmpPath := path + ".tmp"
+
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err = file.Write(buf.Bytes()); err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+func (journal *uploadJournal) append(record journalRecord) error {
+	if journal.file == nil {
+		return nil
+	}
+
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to encode journal record")
+	}
+
+	if _, err = journal.file.Write(append(encoded, '\n')); err != nil {
+		return errors.WithMessage(err, "Failed to write journal")
+	}
+
+	if err = journal.file.Sync(); err != nil {
+		return errors.WithMessage(err, "Failed to sync journal")
+	}
+
+	return nil
+}
+
+// write appends record to journal, and only logs a warning if failed, since journal is
+// only used to resume upload, and should not break the upload.
+func (journal *uploadJournal) write(record journalRecord) {
+	if err := journal.append(record); err != nil {
+		logrus.WithError(err).WithField("journal", journal.path).Warn("Failed to write upload journal")
+	}
+}
+
+// TxHash returns the recorded hash of transaction to submit log entry.
+func (journal *uploadJournal) TxHash() *common.Hash {
+	if journal == nil {
+		return nil
+	}
+
+	return journal.txHash
+}
+
+// TxSeq returns the recorded submission index of log entry.
+func (journal *uploadJournal) TxSeq() *uint64 {
+	if journal == nil {
+		return nil
+	}
+
+	return journal.txSeq
+}
+
+// Acked indicates whether the specified segment has been uploaded to all storage nodes, which
+// is acknowledged only after every storage node accepted the segment.
+func (journal *uploadJournal) Acked(segIndex uint64) bool {
+	if journal == nil {
+		return false
+	}
+
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	return journal.acked[segIndex]
+}
+
+// RecordTx records the hash of transaction sent to submit log entry.
+func (journal *uploadJournal) RecordTx(txHash common.Hash) {
+	if journal == nil {
+		return
+	}
+
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	journal.txHash = &txHash
+
+	journal.write(journalRecord{TxHash: &txHash})
+}
+
+// RecordTxSeq records the submission index of log entry once transaction executed.
+func (journal *uploadJournal) RecordTxSeq(txSeq uint64) {
+	if journal == nil {
+		return
+	}
+
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	journal.txSeq = &txSeq
+
+	journal.write(journalRecord{TxSeq: &txSeq})
+}
+
+// Ack records that the specified segment has been uploaded to all storage nodes.
+func (journal *uploadJournal) Ack(segIndex uint64) {
+	if journal == nil {
+		return
+	}
+
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	journal.acked[segIndex] = true
+
+	journal.write(journalRecord{Segment: &segIndex})
+}
+
+// Close closes the journal file.
+func (journal *uploadJournal) Close() error {
+	if journal == nil || journal.file == nil {
+		return nil
+	}
+
+	return journal.file.Close()
+}
+
+// Remove closes and removes the journal file once upload completed.
+func (journal *uploadJournal) Remove() error {
+	if journal == nil || journal.file == nil {
+		return nil
+	}
+
+	journal.Close()
+
+	return os.Remove(journal.path)
+}
+
+// loadEncryptionHeader returns the encryption header recorded in journal, so that the file could
+// be encrypted with the same salt to resume upload. Returns nil if not recorded, or the file
+// changed since last upload, in which case header must not be reused.
+func loadEncryptionHeader(path string, info os.FileInfo) *encryption.Header {
+	records, complete, err := readJournalRecords(path + encryptionJournalSuffix)
+	if err != nil || !complete || len(records) != 1 {
+		return nil
+	}
+
+	r := records[0]
+	if r.Size == nil || *r.Size != info.Size() ||
+		r.ModTime == nil || *r.ModTime != info.ModTime().UnixNano() ||
+		r.Inode == nil || *r.Inode != fileInode(info) {
+		return nil
+	}
+
+	header, err := encryption.DecodeHeader(r.Encryption)
+	if err != nil {
+		return nil
+	}
+
+	return header
+}
+
+// saveEncryptionHeader records the encryption header of the specified file in journal.
+func saveEncryptionHeader(path string, info os.FileInfo, header *encryption.Header) error {
+	size, modTime, inode := info.Size(), info.ModTime().UnixNano(), fileInode(info)
+
+	return rewriteJournal(path+encryptionJournalSuffix, []journalRecord{{
+		Size:       &size,
+		Encryption: header.Encode(),
+		ModTime:    &modTime,
+		Inode:      &inode,
+	}})
+}
+
+// removeEncryptionHeader removes the recorded encryption header once upload completed.
+func removeEncryptionHeader(path string) {
+	if err := os.Remove(path + encryptionJournalSuffix); err != nil && !os.IsNotExist(err) {
+		logrus.WithError(err).WithField("journal", path).Warn("Failed to remove encryption journal")
+	}
+}
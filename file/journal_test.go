@@ -0,0 +1,124 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/file/encryption"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data"+JournalSuffix)
+	root := common.HexToHash("0x01")
+
+	journal, err := openUploadJournal(path, root, 1024)
+	assert.NoError(t, err)
+	assert.Nil(t, journal.TxHash())
+	assert.Nil(t, journal.TxSeq())
+
+	journal.RecordTx(common.HexToHash("0x02"))
+	journal.RecordTxSeq(3)
+	journal.Ack(5)
+	journal.Ack(7)
+	assert.NoError(t, journal.Close())
+
+	// reopen journal to resume
+	journal, err = openUploadJournal(path, root, 1024)
+	assert.NoError(t, err)
+	assert.Equal(t, common.HexToHash("0x02"), *journal.TxHash())
+	assert.Equal(t, uint64(3), *journal.TxSeq())
+	assert.True(t, journal.Acked(5))
+	assert.True(t, journal.Acked(7))
+	assert.False(t, journal.Acked(6))
+
+	assert.NoError(t, journal.Remove())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUploadJournalPartialRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data"+JournalSuffix)
+	root := common.HexToHash("0x01")
+
+	journal, err := openUploadJournal(path, root, 1024)
+	assert.NoError(t, err)
+	journal.Ack(1)
+	assert.NoError(t, journal.Close())
+
+	// simulate process crashed while writing record
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0666)
+	assert.NoError(t, err)
+	_, err = file.WriteString(`{"segment":2}`)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	journal, err = openUploadJournal(path, root, 1024)
+	assert.NoError(t, err)
+	assert.True(t, journal.Acked(1))
+	assert.False(t, journal.Acked(2))
+
+	journal.Ack(3)
+	assert.NoError(t, journal.Close())
+
+	journal, err = openUploadJournal(path, root, 1024)
+	assert.NoError(t, err)
+	assert.True(t, journal.Acked(1))
+	assert.True(t, journal.Acked(3))
+	assert.NoError(t, journal.Close())
+}
+
+func TestUploadJournalMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data"+JournalSuffix)
+
+	journal, err := openUploadJournal(path, common.HexToHash("0x01"), 1024)
+	assert.NoError(t, err)
+	journal.RecordTx(common.HexToHash("0x02"))
+	journal.Ack(1)
+	assert.NoError(t, journal.Close())
+
+	// file changed since last upload
+	journal, err = openUploadJournal(path, common.HexToHash("0x01"), 2048)
+	assert.NoError(t, err)
+	assert.Nil(t, journal.TxHash())
+	assert.False(t, journal.Acked(1))
+	assert.NoError(t, journal.Close())
+}
+
+func TestEncryptionJournal(t *testing.T) {
+	key, err := encryption.NewRawKey(make([]byte, encryption.KeySize))
+	assert.NoError(t, err)
+
+	name := createTestFile(t, make([]byte, 1000))
+	journalPath := name + JournalSuffix
+
+	encryptRoot := func() common.Hash {
+		file, err := Open(name)
+		assert.NoError(t, err)
+		defer file.Close()
+
+		encrypted, err := encryptFile(file, key, journalPath)
+		assert.NoError(t, err)
+
+		root, err := encrypted.MerkleRoot()
+		assert.NoError(t, err)
+
+		return root
+	}
+
+	// encrypted with the same salt to resume upload
+	root := encryptRoot()
+	assert.Equal(t, root, encryptRoot())
+
+	// file changed since last upload
+	modTime := time.Now().Add(time.Hour)
+	assert.NoError(t, os.Chtimes(name, modTime, modTime))
+	assert.NotEqual(t, root, encryptRoot())
+
+	removeEncryptionHeader(journalPath)
+	_, err = os.Stat(journalPath + encryptionJournalSuffix)
+	assert.True(t, os.IsNotExist(err))
+}
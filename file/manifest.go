@@ -0,0 +1,135 @@
+package file
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+const (
+	// manifestMagic is the prefix of encoded folder manifest to distinguish from normal files.
+	manifestMagic = "IONIAN_MANIFEST\n"
+
+	// partsManifestMagic is the prefix of encoded parts manifest to distinguish from folder manifest.
+	partsManifestMagic = "IONIAN_PARTS_MANIFEST\n"
+
+	manifestVersion = 1
+)
+
+// Manifest types, folder by default.
+const (
+	ManifestTypeDir   = ""
+	ManifestTypeParts = "parts" // parts of a large file in order
+)
+
+// ManifestEntry represents a file or folder in manifest.
+type ManifestEntry struct {
+	Path string      `json:"path"`           // relative path separated by slash
+	Root common.Hash `json:"root,omitempty"` // file merkle root, empty for folder or empty file
+	Size int64       `json:"size"`           // file size in bytes
+	Mode os.FileMode `json:"mode"`           // file mode and permission bits
+}
+
+// Manifest maps relative paths to merkle roots of files, so that a directory could be
+// uploaded and downloaded by a single manifest root.
+type Manifest struct {
+	Version int             `json:"version"`
+	Type    string          `json:"type,omitempty"`
+	Entries []ManifestEntry `json:"entries"`
+}
+
+func NewManifest() *Manifest {
+	return &Manifest{
+		Version: manifestVersion,
+	}
+}
+
+// NewPartsManifest creates a manifest that ties the part roots of a large file together.
+func NewPartsManifest() *Manifest {
+	return &Manifest{
+		Version: manifestVersion,
+		Type:    ManifestTypeParts,
+	}
+}
+
+func (manifest *Manifest) Add(entry ManifestEntry) {
+	manifest.Entries = append(manifest.Entries, entry)
+}
+
+func (manifest *Manifest) Encode() ([]byte, error) {
+	encoded, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(manifest.magic()), encoded...), nil
+}
+
+func (manifest *Manifest) magic() string {
+	if manifest.Type == ManifestTypeParts {
+		return partsManifestMagic
+	}
+
+	return manifestMagic
+}
+
+// IsManifest returns whether the specified data is an encoded folder or parts manifest.
+func IsManifest(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(manifestMagic)) || IsPartsManifest(data)
+}
+
+// IsPartsManifest returns whether the specified data is an encoded parts manifest.
+func IsPartsManifest(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(partsManifestMagic))
+}
+
+// DecodeManifest decodes folder or parts manifest, of which the type should match the magic.
+func DecodeManifest(data []byte) (*Manifest, error) {
+	if !IsManifest(data) {
+		return nil, errors.New("Invalid manifest magic")
+	}
+
+	magic, expectedType := manifestMagic, ManifestTypeDir
+	if IsPartsManifest(data) {
+		magic, expectedType = partsManifestMagic, ManifestTypeParts
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data[len(magic):], &manifest); err != nil {
+		return nil, errors.WithMessage(err, "Failed to unmarshal manifest")
+	}
+
+	if manifest.Version != manifestVersion {
+		return nil, errors.Errorf("Unsupported manifest version %v", manifest.Version)
+	}
+
+	if manifest.Type != expectedType {
+		return nil, errors.Errorf("Manifest type mismatch with magic, type = %v", manifest.Type)
+	}
+
+	for _, entry := range manifest.Entries {
+		if err := validateManifestPath(entry.Path); err != nil {
+			return nil, errors.WithMessagef(err, "Invalid manifest entry %v", entry.Path)
+		}
+	}
+
+	return &manifest, nil
+}
+
+// validateManifestPath requires relative path that not escapes from the root folder.
+func validateManifestPath(p string) error {
+	if p == "" || path.IsAbs(p) || strings.Contains(p, "\\") {
+		return errors.New("relative path with slash separator required")
+	}
+
+	if cleaned := path.Clean(p); cleaned != p || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return errors.New("path not normalized or out of root folder")
+	}
+
+	return nil
+}
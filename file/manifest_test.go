@@ -0,0 +1,133 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifestSerde(t *testing.T) {
+	manifest := NewManifest()
+	manifest.Add(ManifestEntry{Path: "docs", Mode: os.ModeDir | 0755})
+	manifest.Add(ManifestEntry{Path: "docs/readme.md", Root: common.HexToHash("0x1234"), Size: 1024, Mode: 0644})
+	manifest.Add(ManifestEntry{Path: "empty.txt", Mode: 0600})
+
+	encoded, err := manifest.Encode()
+	assert.NoError(t, err)
+	assert.True(t, IsManifest(encoded))
+
+	decoded, err := DecodeManifest(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, manifest, decoded)
+}
+
+func TestManifestPath(t *testing.T) {
+	assert.NoError(t, validateManifestPath("a.txt"))
+	assert.NoError(t, validateManifestPath("a/b/c.txt"))
+	assert.NoError(t, validateManifestPath("..a/b"))
+
+	assert.Error(t, validateManifestPath(""))
+	assert.Error(t, validateManifestPath("/etc/passwd"))
+	assert.Error(t, validateManifestPath(".."))
+	assert.Error(t, validateManifestPath("../a.txt"))
+	assert.Error(t, validateManifestPath("a/../../b.txt"))
+	assert.Error(t, validateManifestPath("a/./b.txt"))
+	assert.Error(t, validateManifestPath("a\\b.txt"))
+}
+
+func TestPartsManifest(t *testing.T) {
+	manifest := NewPartsManifest()
+	manifest.Add(ManifestEntry{Path: "part-0", Root: common.HexToHash("0x01"), Size: DefaultSegmentSize})
+	manifest.Add(ManifestEntry{Path: "part-1", Root: common.HexToHash("0x02"), Size: 100})
+
+	encoded, err := manifest.Encode()
+	assert.NoError(t, err)
+
+	filename := createTestFile(t, encoded)
+
+	decoded, err := readPartsManifest(filename, int64(len(encoded)))
+	assert.NoError(t, err)
+	assert.Equal(t, manifest, decoded)
+
+	// folder manifest
+	encoded, err = NewManifest().Encode()
+	assert.NoError(t, err)
+
+	dirFilename := createTestFile(t, encoded)
+
+	decoded, err = readPartsManifest(dirFilename, int64(len(encoded)))
+	assert.NoError(t, err)
+	assert.Nil(t, decoded)
+}
+
+func TestManifestMagic(t *testing.T) {
+	parts, err := NewPartsManifest().Encode()
+	assert.NoError(t, err)
+	assert.True(t, IsManifest(parts))
+	assert.True(t, IsPartsManifest(parts))
+
+	dir, err := NewManifest().Encode()
+	assert.NoError(t, err)
+	assert.True(t, IsManifest(dir))
+	assert.False(t, IsPartsManifest(dir))
+
+	// type mismatch with magic
+	_, err = DecodeManifest([]byte(manifestMagic + `{"version":1,"type":"parts","entries":[]}`))
+	assert.Error(t, err)
+	_, err = DecodeManifest([]byte(partsManifestMagic + `{"version":1,"entries":[]}`))
+	assert.Error(t, err)
+}
+
+func TestReassembleParts(t *testing.T) {
+	data := createTestData(3*DefaultSegmentSize + 100)
+	manifest := NewPartsManifest()
+
+	var partFilenames []string
+	for offset := 0; offset < len(data); offset += 2 * DefaultSegmentSize {
+		end := offset + 2*DefaultSegmentSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		partFilename := createTestFile(t, data[offset:end])
+
+		partFilenames = append(partFilenames, partFilename)
+		manifest.Add(ManifestEntry{Path: fmt.Sprintf("part-%v", len(partFilenames)-1), Size: int64(end - offset)})
+	}
+
+	filename := filepath.Join(t.TempDir(), "reassembled")
+	size, err := reassembleParts(manifest, partFilenames, filename)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(data)), size)
+
+	reassembled, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, data, reassembled)
+}
+
+func TestUploadPartSize(t *testing.T) {
+	for _, invalid := range []int64{-DefaultSegmentSize, 1000, DefaultSegmentSize + 1, MaxSubmissionSize + DefaultSegmentSize} {
+		_, err := (&UploadOption{PartSize: invalid}).partSize()
+		assert.Error(t, err)
+	}
+
+	size, err := (&UploadOption{}).partSize()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultPartSize, size)
+
+	size, err = (&UploadOption{PartSize: 4 * DefaultSegmentSize}).partSize()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4*DefaultSegmentSize), size)
+}
+
+func TestCheckPartsManifestSize(t *testing.T) {
+	assert.NoError(t, checkPartsManifestSize(10*DefaultSegmentSize+1, DefaultSegmentSize))
+	assert.NoError(t, checkPartsManifestSize(1<<40, MaxSubmissionSize))
+
+	// parts manifest exceeds part size
+	assert.Error(t, checkPartsManifestSize(10000*DefaultSegmentSize, DefaultSegmentSize))
+}
@@ -111,3 +111,87 @@ func (proof *Proof) validateRoot() bool {
 
 	return hash.Hex() == proof.Lemma[len(proof.Lemma)-1].Hex()
 }
+
+// Concat concatenates the proof with the upper proof, whose leaf node is the root of this proof, e.g.
+// proof of chunk in segment concatenated with proof of segment in file. The concatenated proof could
+// be validated against the upper root with all leaf nodes at the lowest level.
+func (proof *Proof) Concat(upper Proof) Proof {
+	// single leaf node in the lower or upper tree
+	if len(proof.Path) == 0 {
+		return upper
+	}
+
+	if len(upper.Path) == 0 {
+		return *proof
+	}
+
+	var result Proof
+
+	result.Lemma = append(result.Lemma, proof.Lemma[:len(proof.Lemma)-1]...)
+	result.Lemma = append(result.Lemma, upper.Lemma[1:]...)
+
+	result.Path = append(result.Path, proof.Path...)
+	result.Path = append(result.Path, upper.Path...)
+
+	return result
+}
+
+// MarshalBinary encodes proof in compact binary format: number of path flags (1 byte), path flags packed
+// in bits from the lowest bit of the first byte, and then all hashes in lemma.
+func (proof Proof) MarshalBinary() ([]byte, error) {
+	if err := proof.validateFormat(); err != nil {
+		return nil, err
+	}
+
+	if len(proof.Path) > math.MaxUint8 {
+		return nil, errProofWrongFormat
+	}
+
+	data := []byte{byte(len(proof.Path))}
+
+	flags := make([]byte, (len(proof.Path)+7)/8)
+	for i, isLeft := range proof.Path {
+		if isLeft {
+			flags[i/8] |= 1 << (i % 8)
+		}
+	}
+	data = append(data, flags...)
+
+	for _, hash := range proof.Lemma {
+		data = append(data, hash.Bytes()...)
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes proof in compact binary format, see MarshalBinary for more details.
+func (proof *Proof) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return errProofWrongFormat
+	}
+
+	numPath := int(data[0])
+	numLemma := numPath + 2
+	if numPath == 0 {
+		numLemma = 1
+	}
+
+	numFlagBytes := (numPath + 7) / 8
+	if len(data) != 1+numFlagBytes+numLemma*common.HashLength {
+		return errProofWrongFormat
+	}
+
+	path := make([]bool, numPath)
+	for i := range path {
+		path[i] = data[1+i/8]&(1<<(i%8)) > 0
+	}
+
+	lemma := make([]common.Hash, numLemma)
+	for i, offset := 0, 1+numFlagBytes; i < numLemma; i, offset = i+1, offset+common.HashLength {
+		lemma[i] = common.BytesToHash(data[offset : offset+common.HashLength])
+	}
+
+	proof.Lemma, proof.Path = lemma, path
+
+	return nil
+}
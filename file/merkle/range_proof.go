@@ -0,0 +1,126 @@
+package merkle
+
+import (
+	"errors"
+	"math"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var errRangeProofEmpty = errors.New("empty range to validate merkle proof")
+
+// RangeProof represents a merkle proof of contiguous leaf nodes, which is more compact than proofs of
+// each leaf node, since only sibling nodes along the left and right boundaries of range are required.
+type RangeProof struct {
+	// Left contains hashes from bottom to top of sibling nodes on the left side of range.
+	Left []common.Hash `json:"left"`
+
+	// Right contains hashes from bottom to top of sibling nodes on the right side of range.
+	Right []common.Hash `json:"right"`
+}
+
+func (proof *RangeProof) Validate(root common.Hash, contents [][]byte, start, numLeafNodes uint64) error {
+	var hashes []common.Hash
+	for _, content := range contents {
+		hashes = append(hashes, crypto.Keccak256Hash(content))
+	}
+
+	return proof.ValidateHash(root, hashes, start, numLeafNodes)
+}
+
+// ValidateHash validates the hashes of contiguous leaf nodes from the start position against root.
+func (proof *RangeProof) ValidateHash(root common.Hash, leafHashes []common.Hash, start, numLeafNodes uint64) error {
+	if len(leafHashes) == 0 {
+		return errRangeProofEmpty
+	}
+
+	if start+uint64(len(leafHashes)) > numLeafNodes {
+		return errProofPositionMismatch
+	}
+
+	hashes := append([]common.Hash{}, leafHashes...)
+	lo, hi, n := start, start+uint64(len(leafHashes)), numLeafNodes
+	var left, right int
+
+	for ; n > 1; lo, hi, n = lo/2, (hi+1)/2, (n+1)/2 {
+		// left sibling required
+		if lo%2 == 1 {
+			if left >= len(proof.Left) {
+				return errProofWrongFormat
+			}
+
+			hashes = append([]common.Hash{proof.Left[left]}, hashes...)
+			left++
+		}
+
+		// right sibling required, otherwise the last single node is carried up
+		if hi%2 == 1 && hi < n {
+			if right >= len(proof.Right) {
+				return errProofWrongFormat
+			}
+
+			hashes = append(hashes, proof.Right[right])
+			right++
+		}
+
+		next := make([]common.Hash, (len(hashes)+1)/2)
+		for i := 0; i+1 < len(hashes); i += 2 {
+			next[i/2] = crypto.Keccak256Hash(hashes[i].Bytes(), hashes[i+1].Bytes())
+		}
+
+		if len(hashes)%2 > 0 {
+			next[len(next)-1] = hashes[len(hashes)-1]
+		}
+
+		hashes = next
+	}
+
+	if left != len(proof.Left) || right != len(proof.Right) {
+		return errProofWrongFormat
+	}
+
+	if hashes[0] != root {
+		return errProofValidationFailure
+	}
+
+	return nil
+}
+
+// MarshalBinary encodes proof in compact binary format: number of left hashes (1 byte), number of right
+// hashes (1 byte), and then left hashes followed by right hashes.
+func (proof RangeProof) MarshalBinary() ([]byte, error) {
+	if len(proof.Left) > math.MaxUint8 || len(proof.Right) > math.MaxUint8 {
+		return nil, errProofWrongFormat
+	}
+
+	data := []byte{byte(len(proof.Left)), byte(len(proof.Right))}
+
+	for _, hash := range append(append([]common.Hash{}, proof.Left...), proof.Right...) {
+		data = append(data, hash.Bytes()...)
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes proof in compact binary format, see MarshalBinary for more details.
+func (proof *RangeProof) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return errProofWrongFormat
+	}
+
+	numLeft, numRight := int(data[0]), int(data[1])
+	if len(data) != 2+(numLeft+numRight)*common.HashLength {
+		return errProofWrongFormat
+	}
+
+	hashes := make([]common.Hash, numLeft+numRight)
+	for i := range hashes {
+		offset := 2 + i*common.HashLength
+		hashes[i] = common.BytesToHash(data[offset : offset+common.HashLength])
+	}
+
+	proof.Left, proof.Right = hashes[:numLeft], hashes[numLeft:]
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package merkle
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRangeProof(t *testing.T) {
+	for numChunks := 1; numChunks <= 32; numChunks++ {
+		tree := createTreeByChunks(numChunks)
+
+		for start := 0; start < numChunks; start++ {
+			for end := start + 1; end <= numChunks; end++ {
+				var contents [][]byte
+				for i := start; i < end; i++ {
+					contents = append(contents, createChunkData(i))
+				}
+
+				proof := tree.RangeProofAt(start, end)
+				assert.NoError(t, proof.Validate(tree.Root(), contents, uint64(start), uint64(numChunks)))
+
+				// wrong position
+				if start > 0 {
+					assert.Error(t, proof.Validate(tree.Root(), contents, uint64(start-1), uint64(numChunks)))
+				}
+
+				// wrong content
+				contents[0] = []byte("bad chunk data")
+				assert.Error(t, proof.Validate(tree.Root(), contents, uint64(start), uint64(numChunks)))
+			}
+		}
+	}
+}
+
+func TestRangeProofEmpty(t *testing.T) {
+	var proof RangeProof
+	assert.Error(t, proof.ValidateHash(common.Hash{}, nil, 0, 1))
+}
+
+func TestProofConcat(t *testing.T) {
+	const chunksPerSegment = 4
+
+	for numChunks := 1; numChunks <= 64; numChunks++ {
+		tree := createTreeByChunks(numChunks)
+
+		// build segment trees and file tree by segment roots
+		var segmentTrees []*Tree
+		var fileBuilder TreeBuilder
+
+		for i := 0; i < numChunks; i += chunksPerSegment {
+			var segBuilder TreeBuilder
+			for j := i; j < i+chunksPerSegment && j < numChunks; j++ {
+				segBuilder.Append(createChunkData(j))
+			}
+
+			segTree := segBuilder.Build()
+			segmentTrees = append(segmentTrees, segTree)
+			fileBuilder.AppendHash(segTree.Root())
+		}
+
+		fileTree := fileBuilder.Build()
+		assert.Equal(t, tree.Root(), fileTree.Root())
+
+		for i := 0; i < numChunks; i++ {
+			segment := i / chunksPerSegment
+			lower := segmentTrees[segment].ProofAt(i % chunksPerSegment)
+			proof := lower.Concat(fileTree.ProofAt(segment))
+
+			assert.Equal(t, tree.ProofAt(i), proof)
+			assert.NoError(t, proof.Validate(tree.Root(), createChunkData(i), uint64(i), uint64(numChunks)))
+		}
+	}
+}
+
+func TestRangeProofBinary(t *testing.T) {
+	tree := createTreeByChunks(13)
+
+	for start := 0; start < 13; start++ {
+		proof := tree.RangeProofAt(start, start+1+(12-start)/2)
+
+		data, err := proof.MarshalBinary()
+		assert.NoError(t, err)
+
+		var decoded RangeProof
+		assert.NoError(t, decoded.UnmarshalBinary(data))
+		assert.Equal(t, proof, decoded)
+
+		assert.Error(t, decoded.UnmarshalBinary(data[:len(data)-1]))
+	}
+}
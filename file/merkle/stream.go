@@ -0,0 +1,62 @@
+package merkle
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// subtree is a complete binary subtree of 2^height leaf nodes.
+type subtree struct {
+	hash   common.Hash
+	height int
+}
+
+// StreamingTreeBuilder calculates the merkle root in streaming way with O(log n) memory, which is
+// the same as the root of tree built by TreeBuilder. Use TreeBuilder instead if merkle proof required.
+//
+// Leaf nodes are merged into complete subtrees on the fly, e.g. 7 leaf nodes are merged into subtrees
+// of 4, 2 and 1 leaf nodes. The root is calculated by merging subtrees from right to left, which is
+// equivalent to carry up the last single node of each level in TreeBuilder.
+type StreamingTreeBuilder struct {
+	subtrees     []subtree // from left to right with descending height
+	numLeafNodes uint64
+}
+
+func (builder *StreamingTreeBuilder) Append(content []byte) {
+	builder.AppendHash(crypto.Keccak256Hash(content))
+}
+
+func (builder *StreamingTreeBuilder) AppendHash(hash common.Hash) {
+	current := subtree{hash, 0}
+
+	// merge subtrees of the same height
+	for n := len(builder.subtrees); n > 0 && builder.subtrees[n-1].height == current.height; n-- {
+		left := builder.subtrees[n-1]
+		current = subtree{crypto.Keccak256Hash(left.hash.Bytes(), current.hash.Bytes()), current.height + 1}
+		builder.subtrees = builder.subtrees[:n-1]
+	}
+
+	builder.subtrees = append(builder.subtrees, current)
+	builder.numLeafNodes++
+}
+
+// NumLeafNodes returns the number of leaf nodes appended.
+func (builder *StreamingTreeBuilder) NumLeafNodes() uint64 {
+	return builder.numLeafNodes
+}
+
+// Root returns the merkle root of leaf nodes appended so far, which is empty hash if no leaf node.
+// Note, more leaf nodes could be appended after Root called.
+func (builder *StreamingTreeBuilder) Root() common.Hash {
+	n := len(builder.subtrees)
+	if n == 0 {
+		return common.Hash{}
+	}
+
+	root := builder.subtrees[n-1].hash
+	for i := n - 2; i >= 0; i-- {
+		root = crypto.Keccak256Hash(builder.subtrees[i].hash.Bytes(), root.Bytes())
+	}
+
+	return root
+}
@@ -0,0 +1,20 @@
+package merkle
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamingTreeRoot(t *testing.T) {
+	var builder StreamingTreeBuilder
+	assert.Equal(t, common.Hash{}, builder.Root())
+
+	for numChunks := 1; numChunks <= 300; numChunks++ {
+		builder.Append(createChunkData(numChunks - 1))
+
+		assert.Equal(t, uint64(numChunks), builder.NumLeafNodes())
+		assert.Equal(t, createTreeByChunks(numChunks).Root(), builder.Root())
+	}
+}
@@ -5,24 +5,31 @@ import (
 )
 
 // Tree represents a binary merkle tree, e.g. bitcoin-like merkle tree or complete BMT.
+//
+// Nodes are stored level by level in arrays instead of linked nodes, so as to reduce memory
+// for large tree. Note, the last single node of a level is carried up to the next level.
 type Tree struct {
-	root      *node // always not nil
-	leafNodes []*node
+	levels [][]common.Hash // from leaf nodes to root, and the top level always has a single node
 }
 
 func (tree *Tree) Root() common.Hash {
-	return tree.root.hash
+	return tree.levels[len(tree.levels)-1][0]
+}
+
+// NumLeafNodes returns the number of leaf nodes.
+func (tree *Tree) NumLeafNodes() int {
+	return len(tree.levels[0])
 }
 
 func (tree *Tree) ProofAt(i int) Proof {
-	if i < 0 || i >= len(tree.leafNodes) {
+	if i < 0 || i >= len(tree.levels[0]) {
 		panic("index out of bound")
 	}
 
 	// only single root node
-	if len(tree.leafNodes) == 1 {
+	if len(tree.levels[0]) == 1 {
 		return Proof{
-			Lemma: []common.Hash{tree.root.hash},
+			Lemma: []common.Hash{tree.Root()},
 			Path:  []bool{},
 		}
 	}
@@ -30,20 +37,50 @@ func (tree *Tree) ProofAt(i int) Proof {
 	var proof Proof
 
 	// append the target leaf node hash
-	proof.Lemma = append(proof.Lemma, tree.leafNodes[i].hash)
+	proof.Lemma = append(proof.Lemma, tree.levels[0][i])
 
-	for current := tree.leafNodes[i]; current != tree.root; current = current.parent {
-		if current.isLeftSide() {
-			proof.Lemma = append(proof.Lemma, current.parent.right.hash)
-			proof.Path = append(proof.Path, true)
-		} else {
-			proof.Lemma = append(proof.Lemma, current.parent.left.hash)
+	for _, level := range tree.levels[:len(tree.levels)-1] {
+		if i%2 == 1 {
+			proof.Lemma = append(proof.Lemma, level[i-1])
 			proof.Path = append(proof.Path, false)
+		} else if i+1 < len(level) {
+			proof.Lemma = append(proof.Lemma, level[i+1])
+			proof.Path = append(proof.Path, true)
 		}
+
+		// otherwise, the last single node is carried up without sibling
+		i /= 2
 	}
 
 	// append the root node hash
-	proof.Lemma = append(proof.Lemma, tree.root.hash)
+	proof.Lemma = append(proof.Lemma, tree.Root())
+
+	return proof
+}
+
+// RangeProofAt returns the merkle proof of contiguous leaf nodes in range [start, end).
+func (tree *Tree) RangeProofAt(start, end int) RangeProof {
+	if start < 0 || start >= end || end > len(tree.levels[0]) {
+		panic("range out of bound")
+	}
+
+	proof := RangeProof{
+		Left:  []common.Hash{},
+		Right: []common.Hash{},
+	}
+
+	for _, level := range tree.levels[:len(tree.levels)-1] {
+		if start%2 == 1 {
+			proof.Left = append(proof.Left, level[start-1])
+		}
+
+		// otherwise, the last single node is carried up without sibling
+		if end%2 == 1 && end < len(level) {
+			proof.Right = append(proof.Right, level[end])
+		}
+
+		start, end = start/2, (end+1)/2
+	}
 
 	return proof
 }
@@ -1,24 +1,21 @@
 package merkle
 
 import (
-	"container/list"
-
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 // TreeBuilder is used to build complete binary merkle tree.
 type TreeBuilder struct {
-	leafNodes []*node
+	leafNodes []common.Hash
 }
 
 func (builder *TreeBuilder) Append(content []byte) {
-	node := newLeafNode(content)
-	builder.leafNodes = append(builder.leafNodes, node)
+	builder.leafNodes = append(builder.leafNodes, crypto.Keccak256Hash(content))
 }
 
 func (builder *TreeBuilder) AppendHash(hash common.Hash) {
-	node := newNode(hash)
-	builder.leafNodes = append(builder.leafNodes, node)
+	builder.leafNodes = append(builder.leafNodes, hash)
 }
 
 func (builder *TreeBuilder) Build() *Tree {
@@ -27,43 +24,23 @@ func (builder *TreeBuilder) Build() *Tree {
 		return nil
 	}
 
-	queue := list.New()
-
-	for i := 0; i < numLeafNodes; i += 2 {
-		// last single leaf node
-		if i == numLeafNodes-1 {
-			queue.PushBack(builder.leafNodes[i])
-			continue
-		}
-
-		left, right := builder.leafNodes[i], builder.leafNodes[i+1]
-
-		node := newInteriorNode(left, right)
-		queue.PushBack(node)
-	}
-
-	for {
-		numNodes := queue.Len()
-		if numNodes <= 1 {
-			break
-		}
+	levels := [][]common.Hash{builder.leafNodes}
 
-		for i := 0; i < numNodes/2; i++ {
-			left := queue.Remove(queue.Front()).(*node)
-			right := queue.Remove(queue.Front()).(*node)
+	for current := builder.leafNodes; len(current) > 1; {
+		next := make([]common.Hash, (len(current)+1)/2)
 
-			node := newInteriorNode(left, right)
-			queue.PushBack(node)
+		for i := 0; i+1 < len(current); i += 2 {
+			next[i/2] = crypto.Keccak256Hash(current[i].Bytes(), current[i+1].Bytes())
 		}
 
 		// last single node
-		if numNodes%2 > 0 {
-			queue.MoveToBack(queue.Front())
+		if len(current)%2 > 0 {
+			next[len(next)-1] = current[len(current)-1]
 		}
-	}
 
-	return &Tree{
-		root:      queue.Front().Value.(*node),
-		leafNodes: builder.leafNodes,
+		levels = append(levels, next)
+		current = next
 	}
+
+	return &Tree{levels}
 }
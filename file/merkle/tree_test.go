@@ -75,3 +75,22 @@ func TestRootBySegment(t *testing.T) {
 		assert.Equal(t, root2, root3)
 	}
 }
+
+func TestProofBinary(t *testing.T) {
+	for numChunks := 1; numChunks <= 20; numChunks++ {
+		tree := createTreeByChunks(numChunks)
+
+		for i := 0; i < numChunks; i++ {
+			proof := tree.ProofAt(i)
+
+			data, err := proof.MarshalBinary()
+			assert.NoError(t, err)
+
+			var decoded Proof
+			assert.NoError(t, decoded.UnmarshalBinary(data))
+			assert.Equal(t, proof, decoded)
+
+			assert.Error(t, decoded.UnmarshalBinary(data[:len(data)-1]))
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package file
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/file/merkle"
+	"github.com/Ionian-Web3-Storage/ionian-client/node"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockNode is a storage node that serves file in memory via JSON RPC for test purpose.
+type mockNode struct {
+	data []byte
+	tree *merkle.Tree
+	info node.FileInfo
+	txs  map[uint64]node.FileInfo // duplicated transactions of the same file
+
+	unavailable bool            // fails to download segments
+	corrupted   bool            // serves corrupted segments
+	truncated   bool            // serves segments shorter than requested
+	badSegments map[uint64]bool // serves corrupted data of the specified segments
+
+	downloads int32 // number of segments served
+	proofs    int32 // number of segments served with proof
+
+	mu          sync.Mutex
+	uploaded    map[uint64]bool // segments uploaded, nil if file already uploaded
+	failUploads int32           // number of segment uploads to fail at first
+}
+
+// newMockNode starts a mock storage node to serve the specified file data.
+func newMockNode(t *testing.T, data []byte) (*mockNode, *node.Client) {
+	file, err := OpenReaderAt(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+
+	tree, err := file.MerkleTree()
+	assert.NoError(t, err)
+
+	mock := mockNode{
+		data: data,
+		tree: tree,
+		info: node.FileInfo{
+			Tx: node.Transaction{
+				DataMerkleRoot: tree.Root(),
+				Size:           uint64(len(data)),
+			},
+			Finalized: true,
+		},
+	}
+
+	server := httptest.NewServer(&mock)
+	t.Cleanup(server.Close)
+
+	client, err := node.NewClient(server.URL)
+	assert.NoError(t, err)
+	t.Cleanup(client.Close)
+
+	return &mock, client
+}
+
+// newMockUploadNode starts a mock storage node that has log entry of the specified file data, and
+// file will be finalized once all segments uploaded.
+func newMockUploadNode(t *testing.T, data []byte) (*mockNode, *node.Client) {
+	mock, client := newMockNode(t, data)
+	mock.info.Finalized = false
+	mock.uploaded = make(map[uint64]bool)
+
+	return mock, client
+}
+
+// numUploaded returns the number of segments uploaded.
+func (mock *mockNode) numUploaded() int {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	return len(mock.uploaded)
+}
+
+// fileInfo returns the file info, which is finalized once all segments uploaded.
+func (mock *mockNode) fileInfo() node.FileInfo {
+	info := mock.info
+
+	if mock.uploaded != nil {
+		numSegments := numSplits(int64(len(mock.data)), DefaultSegmentSize)
+		info.Finalized = uint64(mock.numUploaded()) == numSegments
+	}
+
+	return info
+}
+
+func (mock *mockNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if strings.HasPrefix(req.Method, "ionian_downloadSegment") {
+		if mock.unavailable {
+			http.Error(w, "segment unavailable", http.StatusBadGateway)
+			return
+		}
+
+		atomic.AddInt32(&mock.downloads, 1)
+
+		if req.Method == "ionian_downloadSegmentWithProof" {
+			atomic.AddInt32(&mock.proofs, 1)
+		}
+	}
+
+	var result interface{}
+
+	switch req.Method {
+	case "ionian_getFileInfo":
+		var root common.Hash
+		json.Unmarshal(req.Params[0], &root)
+
+		if root == mock.tree.Root() {
+			result = mock.fileInfo()
+		}
+	case "ionian_getFileInfoByTxSeq":
+		var txSeq uint64
+		json.Unmarshal(req.Params[0], &txSeq)
+
+		if txSeq == mock.info.Tx.Seq {
+			result = mock.info
+		} else if info, ok := mock.txs[txSeq]; ok {
+			result = info
+		}
+	case "ionian_downloadSegment":
+		var startIndex, endIndex uint64
+		json.Unmarshal(req.Params[1], &startIndex)
+		json.Unmarshal(req.Params[2], &endIndex)
+
+		result = mock.chunks(startIndex, endIndex)
+	case "ionian_downloadSegmentWithProof":
+		var index uint64
+		json.Unmarshal(req.Params[1], &index)
+
+		startIndex := index * DefaultSegmentMaxChunks
+		endIndex := startIndex + DefaultSegmentMaxChunks
+		if numChunks := numSplits(int64(len(mock.data)), DefaultChunkSize); endIndex > numChunks {
+			endIndex = numChunks
+		}
+
+		result = node.SegmentWithProof{
+			Root:     mock.tree.Root(),
+			Data:     mock.chunks(startIndex, endIndex),
+			Index:    index,
+			Proof:    mock.tree.ProofAt(int(index)),
+			FileSize: uint64(len(mock.data)),
+		}
+	case "ionian_uploadSegment":
+		if atomic.AddInt32(&mock.failUploads, -1) >= 0 {
+			http.Error(w, "upload unavailable", http.StatusBadGateway)
+			return
+		}
+
+		var segment node.SegmentWithProof
+		json.Unmarshal(req.Params[0], &segment)
+
+		if mock.uploaded == nil || segment.Root != mock.tree.Root() {
+			http.Error(w, "invalid segment", http.StatusBadRequest)
+			return
+		}
+
+		mock.mu.Lock()
+		mock.uploaded[segment.Index] = true
+		mock.mu.Unlock()
+
+		result = 0
+	default:
+		http.Error(w, "method not found", http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      req.ID,
+		"result":  result,
+	})
+}
+
+// chunks returns data of chunks [startIndex, endIndex), and the last chunk is padded with zeros.
+func (mock *mockNode) chunks(startIndex, endIndex uint64) []byte {
+	data := make([]byte, (endIndex-startIndex)*DefaultChunkSize)
+
+	if start := startIndex * DefaultChunkSize; start < uint64(len(mock.data)) {
+		copy(data, mock.data[start:])
+	}
+
+	if mock.corrupted || mock.badSegments[startIndex/DefaultSegmentMaxChunks] {
+		data[0] ^= 1
+	}
+
+	if mock.truncated {
+		return data[:len(data)/2]
+	}
+
+	return data
+}
@@ -0,0 +1,289 @@
+package file
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/node"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMaxRetries       = 5
+	defaultRetryInterval    = time.Second
+	defaultMaxRetryInterval = 30 * time.Second
+	defaultMaxNodeFailures  = 3
+)
+
+// ErrNoNodeAvailable is returned when all storage nodes are benched due to failures.
+var ErrNoNodeAvailable = errors.New("no storage node available")
+
+// RetryOption configures how to retry the failed requests to storage nodes. Any zero field
+// falls back to the default value.
+type RetryOption struct {
+	MaxRetries      int           // max number of retries for a single request, default 5
+	Interval        time.Duration // backoff interval for the first retry, doubled for each retry, default 1s
+	MaxInterval     time.Duration // max backoff interval, default 30s
+	MaxNodeFailures int           // number of consecutive failures to bench a storage node, default 3
+}
+
+func (opt RetryOption) withDefaults() RetryOption {
+	if opt.MaxRetries <= 0 {
+		opt.MaxRetries = defaultMaxRetries
+	}
+
+	if opt.Interval <= 0 {
+		opt.Interval = defaultRetryInterval
+	}
+
+	if opt.MaxInterval < opt.Interval {
+		opt.MaxInterval = defaultMaxRetryInterval
+		if opt.MaxInterval < opt.Interval {
+			opt.MaxInterval = opt.Interval
+		}
+	}
+
+	if opt.MaxNodeFailures <= 0 {
+		opt.MaxNodeFailures = defaultMaxNodeFailures
+	}
+
+	return opt
+}
+
+// nodePool tracks the consecutive failures of storage nodes, and benches the node that keeps
+// failing, so that requests could fall back to other storage nodes.
+type nodePool struct {
+	clients []*node.Client
+	option  RetryOption
+
+	// failover indicates to retry the failed request on the next storage node for any error,
+	// e.g. segment unavailable or invalid merkle proof on a storage node.
+	failover bool
+
+	// states of storage nodes, which are shared with views of the pool
+	mu     *sync.Mutex
+	states []*nodeState
+}
+
+// nodeState is the state of a storage node in pool.
+type nodeState struct {
+	failures int
+	benched  bool
+	limiter  *concurrencyLimiter // limits in-flight requests, nil for unlimited
+}
+
+func newNodePool(clients []*node.Client, option RetryOption) *nodePool {
+	states := make([]*nodeState, len(clients))
+	for i := range states {
+		states[i] = &nodeState{}
+	}
+
+	return &nodePool{
+		clients: clients,
+		option:  option.withDefaults(),
+		mu:      &sync.Mutex{},
+		states:  states,
+	}
+}
+
+// newFailoverNodePool creates a node pool that retries the failed request on other storage nodes.
+func newFailoverNodePool(clients []*node.Client, option RetryOption) *nodePool {
+	pool := newNodePool(clients, option)
+	pool.failover = true
+	return pool
+}
+
+// withConcurrency limits the number of in-flight requests of each storage node, which is adjusted
+// based on observed latency and errors in adaptive mode. See concurrencyLimiter for more details.
+func (pool *nodePool) withConcurrency(perNode int, adaptive bool) *nodePool {
+	for _, state := range pool.states {
+		state.limiter = newConcurrencyLimiter(perNode, adaptive)
+	}
+
+	return pool
+}
+
+// view returns a pool of the specified storage nodes in pool, which shares failures and in-flight
+// requests of storage nodes with the original pool, e.g. to download many files in parallel.
+func (pool *nodePool) view(clients []*node.Client) *nodePool {
+	states := make([]*nodeState, len(clients))
+
+	for i, client := range clients {
+		for j, c := range pool.clients {
+			if c == client {
+				states[i] = pool.states[j]
+				break
+			}
+		}
+
+		if states[i] == nil {
+			panic("storage node not in pool")
+		}
+	}
+
+	return &nodePool{
+		clients:  clients,
+		option:   pool.option,
+		failover: pool.failover,
+		mu:       pool.mu,
+		states:   states,
+	}
+}
+
+// pick returns the preferred storage node if not benched. Otherwise, returns the next available one.
+func (pool *nodePool) pick(preferred int) (int, bool) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	for i := 0; i < len(pool.clients); i++ {
+		index := (preferred + i) % len(pool.clients)
+		if !pool.states[index].benched {
+			return index, true
+		}
+	}
+
+	return 0, false
+}
+
+func (pool *nodePool) succeed(index int) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.states[index].failures = 0
+}
+
+func (pool *nodePool) fail(index int) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	state := pool.states[index]
+
+	state.failures++
+	if state.failures < pool.option.MaxNodeFailures || state.benched {
+		return
+	}
+
+	// always keep the last available storage node, which fails after max retries
+	var available int
+	for _, state := range pool.states {
+		if !state.benched {
+			available++
+		}
+	}
+
+	if available > 1 {
+		state.benched = true
+		logrus.WithField("node", pool.clients[index].URL()).Warn("Storage node benched due to consecutive failures")
+	}
+}
+
+// call executes the specified request on the preferred storage node, and retries with backoff
+// in case of retryable errors. Once the storage node benched, request falls back to other nodes.
+func (pool *nodePool) call(ctx context.Context, preferred int, request func(client *node.Client) error) error {
+	return pool.retry(ctx, preferred, true, request)
+}
+
+// callNode executes the specified request on the specified storage node only, and retries with
+// backoff in case of retryable errors, e.g. to upload segment to every storage node.
+func (pool *nodePool) callNode(ctx context.Context, index int, request func(client *node.Client) error) error {
+	return pool.retry(ctx, index, false, request)
+}
+
+func (pool *nodePool) retry(ctx context.Context, preferred int, fallback bool, request func(client *node.Client) error) error {
+	interval := pool.option.Interval
+
+	for retry := 0; ; retry++ {
+		index, ok := preferred, true
+		if fallback {
+			index, ok = pool.pick(preferred)
+		}
+
+		if !ok {
+			return ErrNoNodeAvailable
+		}
+
+		client := pool.clients[index]
+
+		err := pool.request(ctx, index, request)
+		if err == nil {
+			pool.succeed(index)
+			return nil
+		}
+
+		if !pool.retryable(err) {
+			return err
+		}
+
+		pool.fail(index)
+
+		if retry >= pool.maxRetries() {
+			return errors.WithMessagef(err, "Failed after %v retries", retry)
+		}
+
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"node":     client.URL(),
+			"retry":    retry + 1,
+			"interval": interval,
+		}).Warn("Failed to request storage node, retry later")
+
+		if err = sleep(ctx, interval); err != nil {
+			return err
+		}
+
+		if interval *= 2; interval > pool.option.MaxInterval {
+			interval = pool.option.MaxInterval
+		}
+
+		preferred = index
+		if pool.failover && fallback {
+			preferred = index + 1
+		}
+	}
+}
+
+// request executes the specified request on storage node once allowed by the concurrency limiter.
+func (pool *nodePool) request(ctx context.Context, index int, request func(client *node.Client) error) error {
+	limiter := pool.states[index].limiter
+	if limiter == nil {
+		return request(pool.clients[index])
+	}
+
+	if err := limiter.acquire(ctx); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err := request(pool.clients[index])
+	limiter.release(time.Since(start), err)
+
+	return err
+}
+
+func (pool *nodePool) retryable(err error) bool {
+	if !pool.failover {
+		return node.IsRetryableError(err)
+	}
+
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// maxRetries returns the max number of retries, which allows to try all storage nodes in case of failover.
+func (pool *nodePool) maxRetries() int {
+	if pool.failover && pool.option.MaxRetries < len(pool.clients)-1 {
+		return len(pool.clients) - 1
+	}
+
+	return pool.option.MaxRetries
+}
+
+// sleep pauses for the specified duration, and returns error once the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+package file
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Phase represents a phase of file upload or download.
+type Phase string
+
+const (
+	PhaseHashing         Phase = "hashing"           // calculate file merkle root
+	PhaseSubmitting      Phase = "submitting"        // submit log entry on blockchain
+	PhaseWaitingLogEntry Phase = "waiting_log_entry" // wait for log entry available on storage node
+	PhaseUploading       Phase = "uploading"         // upload segments to storage node
+	PhaseFinalizing      Phase = "finalizing"        // wait for file finalized on storage node
+	PhaseDownloading     Phase = "downloading"       // download segments from storage node
+	PhaseValidating      Phase = "validating"        // validate the downloaded file
+	PhaseDecrypting      Phase = "decrypting"        // decrypt the downloaded file
+	PhaseCompleted       Phase = "completed"         // file uploaded or downloaded
+)
+
+// Progress is the event reported during file upload or download.
+type Progress struct {
+	Phase         Phase       `json:"phase"`
+	Root          common.Hash `json:"root"`          // empty before file merkle root calculated
+	Segments      uint64      `json:"segments"`      // number of segments uploaded or downloaded
+	TotalSegments uint64      `json:"totalSegments"` // total number of segments
+	Bytes         int64       `json:"bytes"`         // number of bytes uploaded or downloaded
+	TotalBytes    int64       `json:"totalBytes"`    // file size in bytes
+	Time          time.Time   `json:"time"`
+}
+
+// ProgressListener is notified with progress events during file upload or download.
+//
+// Note, listener is notified in sequence and should not block for a long time.
+type ProgressListener interface {
+	OnProgress(progress Progress)
+}
+
+// ProgressFunc is an adapter to allow the use of ordinary function as ProgressListener.
+type ProgressFunc func(progress Progress)
+
+// OnProgress implements the ProgressListener interface.
+func (f ProgressFunc) OnProgress(progress Progress) {
+	f(progress)
+}
+
+type progressReporter struct {
+	listener ProgressListener
+	root     common.Hash
+	size     int64
+}
+
+func newProgressReporter(listener ProgressListener, root common.Hash, size int64) *progressReporter {
+	return &progressReporter{listener, root, size}
+}
+
+// report notifies listener with the number of segments that uploaded or downloaded.
+func (reporter *progressReporter) report(phase Phase, segments uint64) {
+	if reporter == nil || reporter.listener == nil {
+		return
+	}
+
+	bytes := int64(segments) * DefaultSegmentSize
+	if bytes > reporter.size {
+		bytes = reporter.size
+	}
+
+	reporter.listener.OnProgress(Progress{
+		Phase:         phase,
+		Root:          reporter.root,
+		Segments:      segments,
+		TotalSegments: numSplits(reporter.size, DefaultSegmentSize),
+		Bytes:         bytes,
+		TotalBytes:    reporter.size,
+		Time:          time.Now(),
+	})
+}
@@ -0,0 +1,127 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// progressRecorder records all progress events for test purpose.
+type progressRecorder struct {
+	mu     sync.Mutex
+	events []Progress
+}
+
+func (recorder *progressRecorder) OnProgress(progress Progress) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	recorder.events = append(recorder.events, progress)
+}
+
+// phases returns the distinct phases in order.
+func (recorder *progressRecorder) phases() []Phase {
+	var phases []Phase
+
+	for _, event := range recorder.events {
+		if len(phases) == 0 || phases[len(phases)-1] != event.Phase {
+			phases = append(phases, event.Phase)
+		}
+	}
+
+	return phases
+}
+
+func TestUploadProgress(t *testing.T) {
+	size := DefaultSegmentSize*3 + 100
+	data := createTestData(size)
+
+	mock, client := newMockUploadNode(t, data)
+
+	var recorder progressRecorder
+	err := NewUploaderLight(client).UploadReaderAt(context.Background(), bytes.NewReader(data), int64(size), UploadOption{
+		Progress: &recorder,
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Phase{PhaseHashing, PhaseUploading, PhaseFinalizing, PhaseCompleted}, recorder.phases())
+
+	last := recorder.events[len(recorder.events)-1]
+	assert.Equal(t, mock.tree.Root(), last.Root)
+	assert.Equal(t, uint64(4), last.Segments)
+	assert.Equal(t, uint64(4), last.TotalSegments)
+	assert.Equal(t, int64(size), last.Bytes)
+	assert.Equal(t, int64(size), last.TotalBytes)
+
+	// segments uploaded in order
+	var segments uint64
+	for _, event := range recorder.events {
+		if event.Phase == PhaseUploading {
+			assert.GreaterOrEqual(t, event.Segments, segments)
+			segments = event.Segments
+		}
+	}
+	assert.Equal(t, uint64(4), segments)
+}
+
+func TestDownloadProgress(t *testing.T) {
+	size := DefaultSegmentSize*3 + 100
+	data := createTestData(size)
+
+	mock, client := newMockNode(t, data)
+
+	var recorder progressRecorder
+	downloader := NewDownloader(client).WithProgress(&recorder)
+
+	filename := filepath.Join(t.TempDir(), "data")
+	assert.NoError(t, downloader.DownloadContext(context.Background(), mock.tree.Root().Hex(), filename, false))
+
+	phases := recorder.phases()
+	assert.Contains(t, phases, PhaseDownloading)
+	assert.Equal(t, PhaseCompleted, phases[len(phases)-1])
+
+	last := recorder.events[len(recorder.events)-1]
+	assert.Equal(t, uint64(4), last.Segments)
+	assert.Equal(t, int64(size), last.Bytes)
+}
+
+func TestPartsProgress(t *testing.T) {
+	size := int64(DefaultSegmentSize*5 + 100)
+
+	var recorder progressRecorder
+
+	report := func(progress *partsProgress, partSize int64) {
+		reporter := newProgressReporter(progress, common.Hash{}, partSize)
+		for segments := uint64(0); segments <= numSplits(partSize, DefaultSegmentSize); segments++ {
+			reporter.report(PhaseUploading, segments)
+		}
+
+		reporter.report(PhaseCompleted, numSplits(partSize, DefaultSegmentSize))
+	}
+
+	// parts of 2 segments, and then the parts manifest
+	report(&partsProgress{&recorder, size, 0, false}, DefaultSegmentSize*2)
+	report(&partsProgress{&recorder, size, DefaultSegmentSize * 2, false}, DefaultSegmentSize*2)
+	report(&partsProgress{&recorder, size, DefaultSegmentSize * 4, false}, DefaultSegmentSize+100)
+	report(&partsProgress{&recorder, size, size, true}, 1000)
+
+	// cumulative over the whole file
+	var bytes int64
+	for _, event := range recorder.events {
+		assert.GreaterOrEqual(t, event.Bytes, bytes)
+		assert.Equal(t, size, event.TotalBytes)
+		assert.Equal(t, uint64(6), event.TotalSegments)
+		bytes = event.Bytes
+	}
+
+	assert.Equal(t, []Phase{PhaseUploading, PhaseCompleted}, recorder.phases())
+
+	last := recorder.events[len(recorder.events)-1]
+	assert.Equal(t, uint64(6), last.Segments)
+	assert.Equal(t, size, last.Bytes)
+}
@@ -0,0 +1,268 @@
+package file
+
+import (
+	"github.com/Ionian-Web3-Storage/ionian-client/file/merkle"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+// NumChunksFlowPadded returns the number of leaf nodes to validate chunk proofs of file in given size.
+func NumChunksFlowPadded(fileSize int64) uint64 {
+	numChunksFlowPadded, _ := computePaddedSize(numSplits(fileSize, DefaultChunkSize))
+	return numChunksFlowPadded
+}
+
+// NumSegmentsFlowPadded returns the number of leaf nodes to validate segment proofs of file in given size.
+func NumSegmentsFlowPadded(fileSize int64) uint64 {
+	return (NumChunksFlowPadded(fileSize)-1)/DefaultSegmentMaxChunks + 1
+}
+
+// SegmentProof returns the merkle proof of specified segment in file, which could be validated against
+// the file merkle root with NumSegmentsFlowPadded leaf nodes.
+func (file *File) SegmentProof(index uint64) (merkle.Proof, error) {
+	if index >= file.NumSegments() {
+		return merkle.Proof{}, errors.Errorf("Segment index out of bound, max = %v", file.NumSegments()-1)
+	}
+
+	tree, err := file.MerkleTree()
+	if err != nil {
+		return merkle.Proof{}, errors.WithMessage(err, "Failed to create file merkle tree")
+	}
+
+	return tree.ProofAt(int(index)), nil
+}
+
+// ChunkProof returns the merkle proof of specified chunk in file, which could be validated against
+// the file merkle root with NumChunksFlowPadded leaf nodes.
+func (file *File) ChunkProof(index uint64) (merkle.Proof, error) {
+	if index >= file.NumChunks() {
+		return merkle.Proof{}, errors.Errorf("Chunk index out of bound, max = %v", file.NumChunks()-1)
+	}
+
+	tree, err := file.MerkleTree()
+	if err != nil {
+		return merkle.Proof{}, errors.WithMessage(err, "Failed to create file merkle tree")
+	}
+
+	segment := index / DefaultSegmentMaxChunks
+
+	segmentTree, err := file.segmentTree(segment)
+	if err != nil {
+		return merkle.Proof{}, errors.WithMessagef(err, "Failed to create merkle tree of segment %v", segment)
+	}
+
+	proof := segmentTree.ProofAt(int(index % DefaultSegmentMaxChunks))
+
+	return proof.Concat(tree.ProofAt(int(segment))), nil
+}
+
+// ChunkRangeProof returns the merkle proof of chunks in range [start, end), which could be validated
+// against the file merkle root with NumChunksFlowPadded leaf nodes.
+func (file *File) ChunkRangeProof(start, end uint64) (merkle.RangeProof, error) {
+	if start >= end || end > file.NumChunks() {
+		return merkle.RangeProof{}, errors.Errorf("Chunk range out of bound, max = %v", file.NumChunks())
+	}
+
+	tree, err := file.MerkleTree()
+	if err != nil {
+		return merkle.RangeProof{}, errors.WithMessage(err, "Failed to create file merkle tree")
+	}
+
+	startSegment := start / DefaultSegmentMaxChunks
+	endSegment := (end-1)/DefaultSegmentMaxChunks + 1
+
+	// sibling nodes on the left side in the first segment
+	firstTree, err := file.segmentTree(startSegment)
+	if err != nil {
+		return merkle.RangeProof{}, errors.WithMessagef(err, "Failed to create merkle tree of segment %v", startSegment)
+	}
+
+	firstEnd := firstTree.NumLeafNodes()
+	if endSegment-startSegment == 1 {
+		firstEnd = int(end - startSegment*DefaultSegmentMaxChunks)
+	}
+
+	first := firstTree.RangeProofAt(int(start%DefaultSegmentMaxChunks), firstEnd)
+
+	// sibling nodes on the right side in the last segment
+	last := first
+	if endSegment-startSegment > 1 {
+		lastTree, err := file.segmentTree(endSegment - 1)
+		if err != nil {
+			return merkle.RangeProof{}, errors.WithMessagef(err, "Failed to create merkle tree of segment %v", endSegment-1)
+		}
+
+		last = lastTree.RangeProofAt(0, int(end-(endSegment-1)*DefaultSegmentMaxChunks))
+	}
+
+	upper := tree.RangeProofAt(int(startSegment), int(endSegment))
+
+	return merkle.RangeProof{
+		Left:  append(first.Left, upper.Left...),
+		Right: append(last.Right, upper.Right...),
+	}, nil
+}
+
+// SegmentRangeProof returns the merkle proof of segments in range [start, end), which could be validated
+// against the file merkle root with NumSegmentsFlowPadded leaf nodes.
+func (file *File) SegmentRangeProof(start, end uint64) (merkle.RangeProof, error) {
+	if start >= end || end > file.NumSegments() {
+		return merkle.RangeProof{}, errors.Errorf("Segment range out of bound, max = %v", file.NumSegments())
+	}
+
+	tree, err := file.MerkleTree()
+	if err != nil {
+		return merkle.RangeProof{}, errors.WithMessage(err, "Failed to create file merkle tree")
+	}
+
+	return tree.RangeProofAt(int(start), int(end)), nil
+}
+
+// segmentTree builds the merkle tree of chunks in specified flow padded segment.
+func (file *File) segmentTree(index uint64) (*merkle.Tree, error) {
+	iter := NewSegmentIterator(file.underlying, file.Size(), int64(index*DefaultSegmentSize), true)
+
+	ok, err := iter.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, errors.New("Segment index out of bound")
+	}
+
+	var builder merkle.TreeBuilder
+
+	data := iter.Current()
+	for offset := 0; offset < len(data); offset += DefaultChunkSize {
+		builder.Append(data[offset : offset+DefaultChunkSize])
+	}
+
+	return builder.Build(), nil
+}
+
+// ValidateChunkProof validates the merkle proof of chunk at specified index in file of given size. Note,
+// the last chunk of file could be less than DefaultChunkSize.
+func ValidateChunkProof(proof merkle.Proof, root common.Hash, chunk []byte, index uint64, fileSize int64) error {
+	hashes, err := chunkHashes(chunk, index, fileSize)
+	if err != nil {
+		return err
+	}
+
+	if len(hashes) != 1 {
+		return errors.Errorf("Invalid chunk size %v", len(chunk))
+	}
+
+	return proof.ValidateHash(root, hashes[0], index, NumChunksFlowPadded(fileSize))
+}
+
+// ValidateChunkRangeProof validates the merkle proof of chunks in data, which starts from the specified chunk
+// index in file of given size.
+func ValidateChunkRangeProof(proof merkle.RangeProof, root common.Hash, data []byte, start uint64, fileSize int64) error {
+	hashes, err := chunkHashes(data, start, fileSize)
+	if err != nil {
+		return err
+	}
+
+	return proof.ValidateHash(root, hashes, start, NumChunksFlowPadded(fileSize))
+}
+
+// ValidateSegmentProof validates the merkle proof of segment at specified index in file of given size. Note,
+// the last segment of file could be less than DefaultSegmentSize.
+func ValidateSegmentProof(proof merkle.Proof, root common.Hash, segment []byte, index uint64, fileSize int64) error {
+	if len(segment) > DefaultSegmentSize {
+		return errors.Errorf("Invalid segment size %v", len(segment))
+	}
+
+	if err := validateRange(int64(len(segment)), int64(index*DefaultSegmentSize), DefaultSegmentSize, fileSize); err != nil {
+		return err
+	}
+
+	leafHash := SegmentLeafHash(segment, index, fileSize)
+
+	return proof.ValidateHash(root, leafHash, index, NumSegmentsFlowPadded(fileSize))
+}
+
+// ValidateSegmentRangeProof validates the merkle proof of segments in data, which starts from the specified
+// segment index in file of given size.
+func ValidateSegmentRangeProof(proof merkle.RangeProof, root common.Hash, data []byte, start uint64, fileSize int64) error {
+	if err := validateRange(int64(len(data)), int64(start*DefaultSegmentSize), DefaultSegmentSize, fileSize); err != nil {
+		return err
+	}
+
+	var hashes []common.Hash
+	for offset, index := 0, start; offset < len(data); offset, index = offset+DefaultSegmentSize, index+1 {
+		end := offset + DefaultSegmentSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		hashes = append(hashes, SegmentLeafHash(data[offset:end], index, fileSize))
+	}
+
+	return proof.ValidateHash(root, hashes, start, NumSegmentsFlowPadded(fileSize))
+}
+
+// chunkHashes returns hashes of chunks in data, which starts from the specified chunk index in file.
+func chunkHashes(data []byte, start uint64, fileSize int64) ([]common.Hash, error) {
+	if err := validateRange(int64(len(data)), int64(start*DefaultChunkSize), DefaultChunkSize, fileSize); err != nil {
+		return nil, err
+	}
+
+	var hashes []common.Hash
+	for offset := 0; offset < len(data); offset += DefaultChunkSize {
+		end := offset + DefaultChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		hashes = append(hashes, ChunkLeafHash(data[offset:end]))
+	}
+
+	return hashes, nil
+}
+
+// ChunkLeafHash returns the leaf hash of chunk to validate merkle proof. Note, the last chunk of file
+// could be less than DefaultChunkSize, and will be padded with zeros.
+func ChunkLeafHash(chunk []byte) common.Hash {
+	if len(chunk) < DefaultChunkSize {
+		padded := make([]byte, DefaultChunkSize)
+		copy(padded, chunk)
+		chunk = padded
+	}
+
+	return crypto.Keccak256Hash(chunk)
+}
+
+// SegmentLeafHash returns the leaf hash of specified segment in file of given size to validate merkle proof,
+// which is the segment root with flow padded chunks. Note, the last segment of file could be unaligned
+// with chunks.
+func SegmentLeafHash(segment []byte, index uint64, fileSize int64) common.Hash {
+	if remainder := len(segment) % DefaultChunkSize; remainder > 0 {
+		segment = append(append([]byte{}, segment...), make([]byte, DefaultChunkSize-remainder)...)
+	}
+
+	numSegmentChunks := uint64(len(segment) / DefaultChunkSize)
+	numChunks := numSplits(fileSize, DefaultChunkSize)
+
+	return segmentRoot(segment, emptyChunksPadded(numChunks, index, numSegmentChunks))
+}
+
+// validateRange validates that data of length starts from the offset in file, which is aligned with unit
+// except the end of file.
+func validateRange(length, offset int64, unit int, fileSize int64) error {
+	if length == 0 {
+		return errors.New("Data is empty")
+	}
+
+	if offset+length > fileSize {
+		return errors.Errorf("Data out of file size %v", fileSize)
+	}
+
+	if length%int64(unit) > 0 && offset+length != fileSize {
+		return errors.Errorf("Data size %v not aligned with %v", length, unit)
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package file
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/file/merkle"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// Units of leaf node to prove.
+const (
+	ProofUnitChunk   = "chunk"
+	ProofUnitSegment = "segment"
+)
+
+// proofExportMagic is the prefix of exported merkle proof in binary format.
+var proofExportMagic = []byte("IONPROOF")
+
+// proofExportHeaderSize is the size of binary format before proof: magic, unit, type, root, file size,
+// index, end and number of leaf nodes.
+var proofExportHeaderSize = len(proofExportMagic) + 2 + common.HashLength + 32
+
+// ProofExport is the exported merkle proof of a chunk or segment, or chunks or segments in range [index, end).
+//
+// Note, all fields are provided by the exporter, and should not be trusted to validate the proof.
+type ProofExport struct {
+	Root         common.Hash        `json:"root"`
+	FileSize     int64              `json:"fileSize"`
+	Unit         string             `json:"unit"`
+	Index        uint64             `json:"index"`
+	End          uint64             `json:"end,omitempty"` // end index (exclusive) of range proof
+	NumLeafNodes uint64             `json:"numLeafNodes"`
+	Proof        *merkle.Proof      `json:"proof,omitempty"`
+	RangeProof   *merkle.RangeProof `json:"rangeProof,omitempty"`
+}
+
+// MarshalBinary encodes in binary format as below, where integers are in big endian:
+//
+//	magic "IONPROOF" | unit (1 byte, 0 chunk, 1 segment) | type (1 byte, 0 proof, 1 range proof) |
+//	root (32 bytes) | file size (8 bytes) | index (8 bytes) | end (8 bytes) | number of leaf nodes (8 bytes) | proof
+//
+// See merkle.Proof.MarshalBinary and merkle.RangeProof.MarshalBinary for the binary format of proof.
+func (export *ProofExport) MarshalBinary() ([]byte, error) {
+	if err := export.validate(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	buf.Write(proofExportMagic)
+
+	if export.Unit == ProofUnitChunk {
+		buf.WriteByte(0)
+	} else {
+		buf.WriteByte(1)
+	}
+
+	var proof []byte
+	var err error
+	if export.RangeProof != nil {
+		buf.WriteByte(1)
+		proof, err = export.RangeProof.MarshalBinary()
+	} else {
+		buf.WriteByte(0)
+		proof, err = export.Proof.MarshalBinary()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	buf.Write(export.Root.Bytes())
+
+	for _, v := range []uint64{uint64(export.FileSize), export.Index, export.End, export.NumLeafNodes} {
+		binary.Write(&buf, binary.BigEndian, v)
+	}
+
+	buf.Write(proof)
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes in binary format, see MarshalBinary for more details.
+func (export *ProofExport) UnmarshalBinary(data []byte) error {
+	if len(data) < proofExportHeaderSize || !bytes.HasPrefix(data, proofExportMagic) {
+		return errors.New("Invalid binary format")
+	}
+
+	data = data[len(proofExportMagic):]
+
+	switch data[0] {
+	case 0:
+		export.Unit = ProofUnitChunk
+	case 1:
+		export.Unit = ProofUnitSegment
+	default:
+		return errors.Errorf("Invalid unit %v", data[0])
+	}
+
+	if data[1] > 1 {
+		return errors.Errorf("Invalid proof type %v", data[1])
+	}
+
+	isRange := data[1] == 1
+
+	export.Root = common.BytesToHash(data[2 : 2+common.HashLength])
+	data = data[2+common.HashLength:]
+
+	export.FileSize = int64(binary.BigEndian.Uint64(data[0:8]))
+	export.Index = binary.BigEndian.Uint64(data[8:16])
+	export.End = binary.BigEndian.Uint64(data[16:24])
+	export.NumLeafNodes = binary.BigEndian.Uint64(data[24:32])
+	data = data[32:]
+
+	export.Proof, export.RangeProof = nil, nil
+
+	if isRange {
+		export.RangeProof = new(merkle.RangeProof)
+		return export.RangeProof.UnmarshalBinary(data)
+	}
+
+	export.Proof = new(merkle.Proof)
+
+	return export.Proof.UnmarshalBinary(data)
+}
+
+func (export *ProofExport) validate() error {
+	if export.Unit != ProofUnitChunk && export.Unit != ProofUnitSegment {
+		return errors.Errorf("Invalid unit %v", export.Unit)
+	}
+
+	if (export.Proof == nil) == (export.RangeProof == nil) {
+		return errors.New("Either proof or range proof required")
+	}
+
+	return nil
+}
+
+// DecodeProofExport decodes exported merkle proof in either binary or JSON format.
+func DecodeProofExport(data []byte) (*ProofExport, error) {
+	var export ProofExport
+
+	if bytes.HasPrefix(data, proofExportMagic) {
+		if err := export.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+	} else if err := json.Unmarshal(data, &export); err != nil {
+		return nil, err
+	}
+
+	if err := export.validate(); err != nil {
+		return nil, err
+	}
+
+	return &export, nil
+}
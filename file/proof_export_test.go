@@ -0,0 +1,91 @@
+package file
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createProofExports(t *testing.T) []*ProofExport {
+	size := int64(DefaultSegmentSize*3 + 1000)
+
+	file, err := OpenReaderAt(bytes.NewReader(createTestData(int(size))), size)
+	assert.NoError(t, err)
+
+	root, err := file.MerkleRoot()
+	assert.NoError(t, err)
+
+	chunkProof, err := file.ChunkProof(5)
+	assert.NoError(t, err)
+
+	segmentProof, err := file.SegmentProof(2)
+	assert.NoError(t, err)
+
+	rangeProof, err := file.SegmentRangeProof(1, 3)
+	assert.NoError(t, err)
+
+	return []*ProofExport{
+		{Root: root, FileSize: size, Unit: ProofUnitChunk, Index: 5, NumLeafNodes: NumChunksFlowPadded(size), Proof: &chunkProof},
+		{Root: root, FileSize: size, Unit: ProofUnitSegment, Index: 2, NumLeafNodes: NumSegmentsFlowPadded(size), Proof: &segmentProof},
+		{Root: root, FileSize: size, Unit: ProofUnitSegment, Index: 1, End: 3, NumLeafNodes: NumSegmentsFlowPadded(size), RangeProof: &rangeProof},
+	}
+}
+
+func TestProofExportSerde(t *testing.T) {
+	for _, export := range createProofExports(t) {
+		// binary format
+		encoded, err := export.MarshalBinary()
+		assert.NoError(t, err)
+
+		decoded, err := DecodeProofExport(encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, export, decoded)
+
+		// JSON format
+		encoded, err = json.Marshal(export)
+		assert.NoError(t, err)
+
+		decoded, err = DecodeProofExport(encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, export, decoded)
+	}
+}
+
+func TestProofExportCorrupted(t *testing.T) {
+	magicSize := len(proofExportMagic)
+
+	for _, export := range createProofExports(t) {
+		encoded, err := export.MarshalBinary()
+		assert.NoError(t, err)
+
+		corrupt := func(offset int, value byte) []byte {
+			data := append([]byte{}, encoded...)
+			data[offset] = value
+			return data
+		}
+
+		for _, data := range [][]byte{
+			corrupt(0, 'X'),                         // magic
+			corrupt(magicSize, 2),                   // unknown unit
+			corrupt(magicSize, 0xFF),                // unknown unit
+			corrupt(magicSize+1, 2),                 // unknown proof type
+			corrupt(magicSize+1, 0xFF),              // unknown proof type
+			encoded[:proofExportHeaderSize-1],       // truncated header
+			encoded[:len(encoded)-1],                // truncated proof
+			append(append([]byte{}, encoded...), 0), // trailing data
+		} {
+			_, err = DecodeProofExport(data)
+			assert.Error(t, err)
+		}
+	}
+
+	// unknown unit in JSON format
+	_, err := DecodeProofExport([]byte(`{"unit":"block","proof":{"lemma":[],"path":[]}}`))
+	assert.Error(t, err)
+
+	// either proof or range proof required
+	_, err = (&ProofExport{Unit: ProofUnitChunk}).MarshalBinary()
+	assert.Error(t, err)
+}
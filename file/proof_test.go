@@ -0,0 +1,145 @@
+package file
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunkProof(t *testing.T) {
+	for _, size := range []int{100, DefaultChunkSize * 3, DefaultSegmentSize*5 + 1000} {
+		data := createTestData(size)
+
+		file, err := OpenReaderAt(bytes.NewReader(data), int64(size))
+		assert.NoError(t, err)
+
+		root, err := file.MerkleRoot()
+		assert.NoError(t, err)
+
+		numChunks := file.NumChunks()
+		for _, index := range []uint64{0, 1, DefaultSegmentMaxChunks - 1, DefaultSegmentMaxChunks, 3000, numChunks - 1} {
+			if index >= numChunks {
+				continue
+			}
+
+			proof, err := file.ChunkProof(index)
+			assert.NoError(t, err)
+
+			chunk := data[index*DefaultChunkSize:]
+			if len(chunk) > DefaultChunkSize {
+				chunk = chunk[:DefaultChunkSize]
+			}
+
+			assert.NoError(t, ValidateChunkProof(proof, root, chunk, index, int64(size)))
+			assert.NoError(t, proof.ValidateHash(root, proof.Lemma[0], index, NumChunksFlowPadded(int64(size))))
+
+			// wrong position
+			assert.Error(t, ValidateChunkProof(proof, root, chunk, index+1, int64(size)))
+		}
+
+		_, err = file.ChunkProof(numChunks)
+		assert.Error(t, err)
+	}
+}
+
+func TestChunkRangeProof(t *testing.T) {
+	size := DefaultSegmentSize*5 + 1000
+	data := createTestData(size)
+
+	file, err := OpenReaderAt(bytes.NewReader(data), int64(size))
+	assert.NoError(t, err)
+
+	root, err := file.MerkleRoot()
+	assert.NoError(t, err)
+
+	numChunks := file.NumChunks()
+	ranges := [][2]uint64{
+		{0, 1},
+		{3, 17}, // within segment
+		{DefaultSegmentMaxChunks - 5, DefaultSegmentMaxChunks}, // end of segment
+		{1000, 3100},                // across segments
+		{0, numChunks},              // whole file
+		{numChunks - 10, numChunks}, // end of file
+	}
+
+	for _, r := range ranges {
+		proof, err := file.ChunkRangeProof(r[0], r[1])
+		assert.NoError(t, err)
+
+		end := int(r[1] * DefaultChunkSize)
+		if end > size {
+			end = size
+		}
+
+		rangeData := data[r[0]*DefaultChunkSize : end]
+		assert.NoError(t, ValidateChunkRangeProof(proof, root, rangeData, r[0], int64(size)))
+
+		// tampered data
+		tampered := append([]byte{}, rangeData...)
+		tampered[0]++
+		assert.Error(t, ValidateChunkRangeProof(proof, root, tampered, r[0], int64(size)))
+	}
+
+	_, err = file.ChunkRangeProof(10, 10)
+	assert.Error(t, err)
+
+	// unaligned data in the middle of file
+	proof, err := file.ChunkRangeProof(0, 2)
+	assert.NoError(t, err)
+	assert.Error(t, ValidateChunkRangeProof(proof, root, data[:DefaultChunkSize+1], 0, int64(size)))
+}
+
+func TestSegmentRangeProof(t *testing.T) {
+	size := DefaultSegmentSize*5 + 1000
+	data := createTestData(size)
+
+	file, err := OpenReaderAt(bytes.NewReader(data), int64(size))
+	assert.NoError(t, err)
+
+	root, err := file.MerkleRoot()
+	assert.NoError(t, err)
+
+	numSegments := file.NumSegments()
+	for start := uint64(0); start < numSegments; start++ {
+		for end := start + 1; end <= numSegments; end++ {
+			proof, err := file.SegmentRangeProof(start, end)
+			assert.NoError(t, err)
+
+			dataEnd := int(end * DefaultSegmentSize)
+			if dataEnd > size {
+				dataEnd = size
+			}
+
+			assert.NoError(t, ValidateSegmentRangeProof(proof, root, data[start*DefaultSegmentSize:dataEnd], start, int64(size)))
+		}
+	}
+}
+
+func TestSegmentProof(t *testing.T) {
+	size := DefaultSegmentSize*5 + 1000
+	data := createTestData(size)
+
+	file, err := OpenReaderAt(bytes.NewReader(data), int64(size))
+	assert.NoError(t, err)
+
+	root, err := file.MerkleRoot()
+	assert.NoError(t, err)
+
+	for index := uint64(0); index < file.NumSegments(); index++ {
+		proof, err := file.SegmentProof(index)
+		assert.NoError(t, err)
+
+		end := int(index+1) * DefaultSegmentSize
+		if end > size {
+			end = size
+		}
+
+		segment := data[index*DefaultSegmentSize : end]
+		assert.NoError(t, ValidateSegmentProof(proof, root, segment, index, int64(size)))
+		assert.Equal(t, proof.Lemma[0], SegmentLeafHash(segment, index, int64(size)))
+	}
+
+	_, err = file.SegmentProof(file.NumSegments())
+	assert.Error(t, err)
+}
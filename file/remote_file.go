@@ -0,0 +1,252 @@
+package file
+
+import (
+	"container/list"
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/node"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// DefaultRemoteFileCacheSize is the default number of segments cached in RemoteFile, e.g. 64 MiB.
+const DefaultRemoteFileCacheSize = 256
+
+// RemoteFile provides random access to a file on storage nodes without downloading the whole
+// file, and implements io.ReaderAt and io.ReadSeeker. Segments are downloaded on demand along
+// with merkle proofs, and only validated segments are cached in memory.
+//
+// Note, ReadAt is safe for concurrent use, but Read and Seek share the same offset.
+type RemoteFile struct {
+	pool      *nodePool
+	root      common.Hash
+	size      int64
+	numChunks uint64
+	next      uint32 // next storage node to download segment in turn
+
+	cache *segmentCache
+
+	mu     sync.Mutex
+	offset int64 // offset for Read and Seek
+}
+
+// OpenRemoteFile opens the file of specified root on storage nodes for random access. Segments
+// are always validated against the file root with merkle proofs, since a single segment could
+// not be validated without proof.
+//
+// Note, ErrRangeNotSupported returned for encrypted or parts split file, in which case the stored
+// data is not the original file.
+func (downloader *Downloader) OpenRemoteFile(ctx context.Context, root string) (*RemoteFile, error) {
+	hash := common.HexToHash(root)
+
+	info, clients, err := downloader.queryFile(ctx, hash)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to query file info")
+	}
+
+	file := &RemoteFile{
+		pool:      downloader.nodePool(clients),
+		root:      hash,
+		size:      int64(info.Tx.Size),
+		numChunks: numSplits(int64(info.Tx.Size), DefaultChunkSize),
+		cache:     newSegmentCache(DefaultRemoteFileCacheSize),
+	}
+
+	err = checkRangeSupported(file.size, func(offset, length int64) ([]byte, error) {
+		data := make([]byte, length)
+		n, err := file.ReadAtContext(ctx, data, offset)
+		return data[:n], err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// WithCacheSize sets the max number of segments cached in memory.
+func (file *RemoteFile) WithCacheSize(segments int) *RemoteFile {
+	file.cache = newSegmentCache(segments)
+	return file
+}
+
+// Root returns the file merkle root.
+func (file *RemoteFile) Root() common.Hash {
+	return file.root
+}
+
+// Size returns the file size in bytes.
+func (file *RemoteFile) Size() int64 {
+	return file.size
+}
+
+// ReadAt implements the io.ReaderAt interface.
+func (file *RemoteFile) ReadAt(p []byte, off int64) (int, error) {
+	return file.ReadAtContext(context.Background(), p, off)
+}
+
+// ReadAtContext reads len(p) bytes from the specified offset, and terminates once the specified
+// context is done.
+func (file *RemoteFile) ReadAtContext(ctx context.Context, p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	n := 0
+	for n < len(p) && off < file.size {
+		segmentIndex := uint64(off / DefaultSegmentSize)
+
+		segment, err := file.segment(ctx, segmentIndex)
+		if err != nil {
+			return n, err
+		}
+
+		copied := copy(p[n:], segment[off-int64(segmentIndex)*DefaultSegmentSize:])
+		n += copied
+		off += int64(copied)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// Read implements the io.Reader interface.
+func (file *RemoteFile) Read(p []byte) (int, error) {
+	file.mu.Lock()
+	defer file.mu.Unlock()
+
+	n, err := file.ReadAt(p, file.offset)
+	file.offset += int64(n)
+
+	// io.Reader returns EOF only if no data read
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+
+	return n, err
+}
+
+// Seek implements the io.Seeker interface.
+func (file *RemoteFile) Seek(offset int64, whence int) (int64, error) {
+	file.mu.Lock()
+	defer file.mu.Unlock()
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += file.offset
+	case io.SeekEnd:
+		offset += file.size
+	default:
+		return 0, errors.Errorf("invalid whence %v", whence)
+	}
+
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	file.offset = offset
+
+	return offset, nil
+}
+
+// segment returns the segment data of specified index without padding, which is downloaded from
+// storage nodes if not cached.
+func (file *RemoteFile) segment(ctx context.Context, index uint64) ([]byte, error) {
+	if segment, ok := file.cache.get(index); ok {
+		return segment, nil
+	}
+
+	startIndex := index * DefaultSegmentMaxChunks
+	endIndex := startIndex + DefaultSegmentMaxChunks
+	if endIndex > file.numChunks {
+		endIndex = file.numChunks
+	}
+
+	var segment []byte
+
+	preferred := int(atomic.AddUint32(&file.next, 1)) % len(file.pool.clients)
+	err := file.pool.call(ctx, preferred, func(client *node.Client) (err error) {
+		segment, _, err = downloadSegmentWithProof(ctx, client, file.root, file.numChunks, startIndex, endIndex)
+		return err
+	})
+
+	if err != nil {
+		return nil, errors.WithMessagef(err, "Failed to download segment %v", index)
+	}
+
+	// remove paddings for the last chunk
+	if end := file.size - int64(index)*DefaultSegmentSize; end < int64(len(segment)) {
+		segment = segment[:end]
+	}
+
+	file.cache.add(index, segment)
+
+	return segment, nil
+}
+
+// segmentCache is a LRU cache of segments.
+type segmentCache struct {
+	capacity int
+
+	mu       sync.Mutex
+	items    *list.List // front is the most recently used
+	elements map[uint64]*list.Element
+}
+
+type segmentCacheItem struct {
+	index uint64
+	data  []byte
+}
+
+func newSegmentCache(capacity int) *segmentCache {
+	return &segmentCache{
+		capacity: capacity,
+		items:    list.New(),
+		elements: make(map[uint64]*list.Element),
+	}
+}
+
+func (cache *segmentCache) get(index uint64) ([]byte, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	element, ok := cache.elements[index]
+	if !ok {
+		return nil, false
+	}
+
+	cache.items.MoveToFront(element)
+
+	return element.Value.(*segmentCacheItem).data, true
+}
+
+func (cache *segmentCache) add(index uint64, data []byte) {
+	if cache.capacity <= 0 {
+		return
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if element, ok := cache.elements[index]; ok {
+		element.Value.(*segmentCacheItem).data = data
+		cache.items.MoveToFront(element)
+		return
+	}
+
+	cache.elements[index] = cache.items.PushFront(&segmentCacheItem{index, data})
+
+	for cache.items.Len() > cache.capacity {
+		oldest := cache.items.Back()
+		cache.items.Remove(oldest)
+		delete(cache.elements, oldest.Value.(*segmentCacheItem).index)
+	}
+}
@@ -0,0 +1,136 @@
+package file
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteFile(t *testing.T) {
+	data := createTestData(3*DefaultSegmentSize + 1000)
+
+	mock, client := newMockNode(t, data)
+
+	file, err := NewDownloader(client).OpenRemoteFile(context.Background(), mock.tree.Root().Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(data)), file.Size())
+
+	// read at random offsets
+	buf := make([]byte, DefaultSegmentSize+10)
+	n, err := file.ReadAt(buf, DefaultSegmentSize-5)
+	assert.NoError(t, err)
+	assert.Equal(t, data[DefaultSegmentSize-5:2*DefaultSegmentSize+5], buf[:n])
+
+	n, err = file.ReadAt(buf, int64(len(data))-100)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, data[len(data)-100:], buf[:n])
+
+	// seek and read all
+	pos, err := file.Seek(-2000, io.SeekEnd)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(data)-2000), pos)
+
+	tail, err := io.ReadAll(file)
+	assert.NoError(t, err)
+	assert.Equal(t, data[len(data)-2000:], tail)
+
+	_, err = file.Seek(0, io.SeekStart)
+	assert.NoError(t, err)
+
+	all, err := io.ReadAll(file)
+	assert.NoError(t, err)
+	assert.Equal(t, data, all)
+}
+
+func TestRemoteFileCorrupted(t *testing.T) {
+	data := createTestData(3*DefaultSegmentSize + 1000)
+
+	mock, client := newMockNode(t, data)
+	mock.badSegments = map[uint64]bool{1: true}
+
+	downloader := NewDownloader(client).WithRetry(RetryOption{
+		MaxRetries: 2,
+		Interval:   time.Millisecond,
+	})
+
+	file, err := downloader.OpenRemoteFile(context.Background(), mock.tree.Root().Hex())
+	assert.NoError(t, err)
+
+	buf := make([]byte, 100)
+	_, err = file.ReadAt(buf, DefaultSegmentSize)
+	assert.Error(t, err)
+
+	// corrupted segment never cached
+	_, ok := file.cache.get(1)
+	assert.False(t, ok)
+}
+
+func TestRemoteFileZip(t *testing.T) {
+	var buf bytes.Buffer
+	content := createTestData(DefaultSegmentSize * 2)
+
+	zw := zip.NewWriter(&buf)
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "data.bin", Method: zip.Store})
+	assert.NoError(t, err)
+	_, err = w.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+
+	mock, client := newMockNode(t, buf.Bytes())
+
+	file, err := NewDownloader(client).OpenRemoteFile(context.Background(), mock.tree.Root().Hex())
+	assert.NoError(t, err)
+
+	zr, err := zip.NewReader(file, file.Size())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(zr.File))
+
+	rc, err := zr.File[0].Open()
+	assert.NoError(t, err)
+	defer rc.Close()
+
+	unzipped, err := io.ReadAll(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, content, unzipped)
+}
+
+func TestRemoteFileNotSupported(t *testing.T) {
+	manifest := NewPartsManifest()
+	manifest.Add(ManifestEntry{Path: "part-0", Size: 1000})
+	data, err := manifest.Encode()
+	assert.NoError(t, err)
+
+	mock, client := newMockNode(t, data)
+
+	_, err = NewDownloader(client).OpenRemoteFile(context.Background(), mock.tree.Root().Hex())
+	assert.ErrorIs(t, err, ErrRangeNotSupported)
+}
+
+func TestSegmentCache(t *testing.T) {
+	cache := newSegmentCache(2)
+
+	cache.add(1, []byte{1})
+	cache.add(2, []byte{2})
+
+	// segment 1 recently used
+	_, ok := cache.get(1)
+	assert.True(t, ok)
+
+	cache.add(3, []byte{3})
+
+	_, ok = cache.get(2)
+	assert.False(t, ok)
+
+	data, ok := cache.get(1)
+	assert.True(t, ok)
+	assert.Equal(t, []byte{1}, data)
+
+	data, ok = cache.get(3)
+	assert.True(t, ok)
+	assert.Equal(t, []byte{3}, data)
+}
@@ -0,0 +1,279 @@
+package file
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// rootCacheHeaderSize is the size of cache entry header: file size, modification time, inode, offset
+// and size of data in file, and number of segment roots, 8 bytes for each.
+const rootCacheHeaderSize = 48
+
+// DefaultRootCacheMaxSize is the default max size of root cache directory, which holds segment roots
+// of about 512 TiB files.
+const DefaultRootCacheMaxSize = 64 * 1024 * 1024
+
+// rootCacheTmpExpiration is the expiration of temp files left by crashed processes.
+const rootCacheTmpExpiration = time.Hour
+
+// RootCache caches segment roots of local files in a directory, so that merkle root need not to be
+// calculated again for unchanged files. Cache entry is keyed by the absolute file path, along with the
+// offset and size of data in file, e.g. part of large file, and becomes invalid once the file size,
+// modification time or inode changed.
+//
+// Segment roots are streamed from or into cache entry, and the least recently used entries are evicted
+// once the total size of cache entries exceeds the max size.
+type RootCache struct {
+	dir     string
+	maxSize int64
+}
+
+// NewRootCache creates a root cache in the specified directory, which will be created if not exists.
+func NewRootCache(dir string) (*RootCache, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, errors.WithMessage(err, "Failed to create cache directory")
+	}
+
+	return &RootCache{dir, DefaultRootCacheMaxSize}, nil
+}
+
+// NewDefaultRootCache creates a root cache in the user cache directory, e.g. ~/.cache/ionian-client/roots.
+func NewDefaultRootCache() (*RootCache, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to get user cache directory")
+	}
+
+	return NewRootCache(filepath.Join(dir, "ionian-client", "roots"))
+}
+
+// WithMaxSize sets the max size of cache entries in bytes, default DefaultRootCacheMaxSize.
+func (cache *RootCache) WithMaxSize(maxSize int64) *RootCache {
+	cache.maxSize = maxSize
+	return cache
+}
+
+// rootCacheKey identifies the data of cache entry, which is in range [offset, offset+size) of file.
+type rootCacheKey struct {
+	path   string
+	info   os.FileInfo // info of the whole file
+	offset int64
+	size   int64
+}
+
+func (key rootCacheKey) header() [6]uint64 {
+	return [6]uint64{
+		uint64(key.info.Size()),
+		uint64(key.info.ModTime().UnixNano()),
+		fileInode(key.info),
+		uint64(key.offset),
+		uint64(key.size),
+		NumSegmentsFlowPadded(key.size),
+	}
+}
+
+// iterate streams the cached segment roots of specified data, which are flow padded. Returns false if
+// not cached, and error if failed to read the cache entry after some roots iterated.
+func (cache *RootCache) iterate(key rootCacheKey, fn func(segRoot common.Hash)) (bool, error) {
+	entry, err := cache.entryPath(key)
+	if err != nil {
+		return false, nil
+	}
+
+	file, err := os.Open(entry)
+	if err != nil {
+		return false, nil
+	}
+	defer file.Close()
+
+	header := key.header()
+
+	info, err := file.Stat()
+	if err != nil || info.Size() != rootCacheHeaderSize+int64(header[5])*common.HashLength {
+		return false, nil
+	}
+
+	reader := bufio.NewReader(file)
+
+	var buf [rootCacheHeaderSize]byte
+	if _, err = io.ReadFull(reader, buf[:]); err != nil {
+		return false, nil
+	}
+
+	for i, v := range header {
+		if binary.BigEndian.Uint64(buf[i*8:]) != v {
+			return false, nil
+		}
+	}
+
+	// mark as recently used for eviction
+	now := time.Now()
+	os.Chtimes(entry, now, now)
+
+	for i := uint64(0); i < header[5]; i++ {
+		var root common.Hash
+		if _, err = io.ReadFull(reader, root[:]); err != nil {
+			return true, errors.WithMessage(err, "Failed to read cached segment root")
+		}
+
+		fn(root)
+	}
+
+	return true, nil
+}
+
+// rootCacheWriter streams segment roots into a temp file, which is renamed to the cache entry once committed,
+// so that a partially written entry is never read.
+type rootCacheWriter struct {
+	cache  *RootCache
+	entry  string
+	file   *os.File
+	writer *bufio.Writer
+	err    error
+}
+
+// create creates a writer to cache the flow padded segment roots of specified data.
+func (cache *RootCache) create(key rootCacheKey) (*rootCacheWriter, error) {
+	entry, err := cache.entryPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.CreateTemp(cache.dir, filepath.Base(entry)+".*.tmp")
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to create temp file")
+	}
+
+	writer := rootCacheWriter{
+		cache:  cache,
+		entry:  entry,
+		file:   file,
+		writer: bufio.NewWriter(file),
+	}
+
+	var buf [rootCacheHeaderSize]byte
+	for i, v := range key.header() {
+		binary.BigEndian.PutUint64(buf[i*8:], v)
+	}
+
+	_, writer.err = writer.writer.Write(buf[:])
+
+	return &writer, nil
+}
+
+func (writer *rootCacheWriter) append(root common.Hash) {
+	if writer.err == nil {
+		_, writer.err = writer.writer.Write(root.Bytes())
+	}
+}
+
+// commit completes the cache entry, and then evicts the least recently used entries if required.
+func (writer *rootCacheWriter) commit() error {
+	err := writer.err
+	if err == nil {
+		err = writer.writer.Flush()
+	}
+
+	if closeErr := writer.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(writer.file.Name(), writer.entry)
+	}
+
+	if err != nil {
+		os.Remove(writer.file.Name())
+		return errors.WithMessage(err, "Failed to write cache entry")
+	}
+
+	if err = writer.cache.evict(); err != nil {
+		logrus.WithError(err).WithField("dir", writer.cache.dir).Warn("Failed to evict root cache")
+	}
+
+	return nil
+}
+
+// abort removes the partially written cache entry, e.g. failed to calculate segment roots.
+func (writer *rootCacheWriter) abort() {
+	writer.file.Close()
+	os.Remove(writer.file.Name())
+}
+
+// evict removes the least recently used entries until the total size does not exceed the max size, and
+// removes the expired temp files left by crashed processes.
+func (cache *RootCache) evict() error {
+	dirEntries, err := os.ReadDir(cache.dir)
+	if err != nil {
+		return err
+	}
+
+	var entries []os.FileInfo
+	var total int64
+
+	for _, v := range dirEntries {
+		info, err := v.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		if strings.HasSuffix(info.Name(), ".tmp") {
+			if time.Since(info.ModTime()) > rootCacheTmpExpiration {
+				os.Remove(filepath.Join(cache.dir, info.Name()))
+			}
+
+			continue
+		}
+
+		entries = append(entries, info)
+		total += info.Size()
+	}
+
+	if total <= cache.maxSize {
+		return nil
+	}
+
+	// least recently used at first
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime().Before(entries[j].ModTime())
+	})
+
+	for _, info := range entries {
+		if total <= cache.maxSize {
+			break
+		}
+
+		if err = os.Remove(filepath.Join(cache.dir, info.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		total -= info.Size()
+	}
+
+	return nil
+}
+
+// entryPath returns the cache entry path of specified data, which is named by hash of the absolute file path
+// along with the offset and size of data in file.
+func (cache *RootCache) entryPath(key rootCacheKey) (string, error) {
+	absPath, err := filepath.Abs(key.path)
+	if err != nil {
+		return "", errors.WithMessage(err, "Failed to get absolute file path")
+	}
+
+	id := fmt.Sprintf("%v:%v:%v", absPath, key.offset, key.size)
+
+	return filepath.Join(cache.dir, crypto.Keccak256Hash([]byte(id)).Hex()[2:]), nil
+}
@@ -0,0 +1,206 @@
+package file
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func openWithRootCache(t *testing.T, filename string, cache *RootCache) *File {
+	file, err := Open(filename)
+	assert.NoError(t, err)
+
+	return file.WithRootCache(cache)
+}
+
+func cachedRoots(cache *RootCache, key rootCacheKey) ([]common.Hash, bool) {
+	var roots []common.Hash
+
+	ok, err := cache.iterate(key, func(segRoot common.Hash) {
+		roots = append(roots, segRoot)
+	})
+
+	return roots, ok && err == nil
+}
+
+func putRoots(t *testing.T, cache *RootCache, key rootCacheKey, roots []common.Hash) {
+	writer, err := cache.create(key)
+	assert.NoError(t, err)
+
+	for _, root := range roots {
+		writer.append(root)
+	}
+
+	assert.NoError(t, writer.commit())
+}
+
+func wholeFileKey(file *File) rootCacheKey {
+	return rootCacheKey{file.path, file.diskInfo, 0, file.Size()}
+}
+
+func TestRootCache(t *testing.T) {
+	cache, err := NewRootCache(t.TempDir())
+	assert.NoError(t, err)
+
+	filename := createTestFile(t, createTestData(DefaultSegmentSize*3+1000))
+
+	// calculate and cache segment roots
+	file := openWithRootCache(t, filename, cache)
+	expected, err := file.MerkleRoot()
+	assert.NoError(t, err)
+	file.Close()
+
+	roots, ok := cachedRoots(cache, wholeFileKey(file))
+	assert.True(t, ok)
+	assert.Equal(t, int(NumSegmentsFlowPadded(file.Size())), len(roots))
+
+	// load from cache
+	file = openWithRootCache(t, filename, cache)
+	tree, err := file.MerkleTree()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, tree.Root())
+	file.Close()
+
+	// cached roots are used if file unchanged
+	putRoots(t, cache, wholeFileKey(file), make([]common.Hash, len(roots)))
+
+	file = openWithRootCache(t, filename, cache)
+	root, err := file.MerkleRoot()
+	assert.NoError(t, err)
+	assert.NotEqual(t, expected, root)
+	file.Close()
+
+	// invalidated once file modified
+	modTime := file.ModTime().Add(time.Second)
+	assert.NoError(t, os.Chtimes(filename, modTime, modTime))
+
+	file = openWithRootCache(t, filename, cache)
+	root, err = file.MerkleRoot()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, root)
+	file.Close()
+
+	// no cache
+	file = openWithRootCache(t, filename, nil)
+	root, err = file.MerkleRoot()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, root)
+	file.Close()
+}
+
+func TestRootCacheParts(t *testing.T) {
+	cache, err := NewRootCache(t.TempDir())
+	assert.NoError(t, err)
+
+	data := createTestData(DefaultSegmentSize*5 + 1000)
+	filename := createTestFile(t, data)
+
+	file := openWithRootCache(t, filename, cache)
+	defer file.Close()
+
+	for _, offset := range []int64{0, 2 * DefaultSegmentSize, 4 * DefaultSegmentSize} {
+		size := int64(2 * DefaultSegmentSize)
+		if offset+size > file.Size() {
+			size = file.Size() - offset
+		}
+
+		part, err := file.Part(offset, size)
+		assert.NoError(t, err)
+
+		expected, err := OpenReaderAt(bytes.NewReader(data[offset:offset+size]), size)
+		assert.NoError(t, err)
+		expectedRoot, err := expected.MerkleRoot()
+		assert.NoError(t, err)
+
+		root, err := part.MerkleRoot()
+		assert.NoError(t, err)
+		assert.Equal(t, expectedRoot, root)
+
+		// parts are cached separately along with offset
+		_, ok := cachedRoots(cache, rootCacheKey{filename, file.diskInfo, offset, size})
+		assert.True(t, ok, "offset = %v", offset)
+
+		root, err = part.MerkleRoot()
+		assert.NoError(t, err)
+		assert.Equal(t, expectedRoot, root)
+	}
+
+	_, err = file.Part(4*DefaultSegmentSize, 2*DefaultSegmentSize)
+	assert.Error(t, err)
+}
+
+func TestRootCacheCorrupted(t *testing.T) {
+	cache, err := NewRootCache(t.TempDir())
+	assert.NoError(t, err)
+
+	filename := createTestFile(t, createTestData(1000))
+
+	file := openWithRootCache(t, filename, cache)
+	defer file.Close()
+
+	_, ok := cachedRoots(cache, wholeFileKey(file))
+	assert.False(t, ok)
+
+	// mismatch with the number of segments
+	putRoots(t, cache, wholeFileKey(file), make([]common.Hash, 2))
+	_, ok = cachedRoots(cache, wholeFileKey(file))
+	assert.False(t, ok)
+
+	// truncated entry
+	entry, err := cache.entryPath(wholeFileKey(file))
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(entry, []byte{1, 2, 3}, 0644))
+	_, ok = cachedRoots(cache, wholeFileKey(file))
+	assert.False(t, ok)
+}
+
+func TestRootCacheEviction(t *testing.T) {
+	dir := t.TempDir()
+
+	cache, err := NewRootCache(dir)
+	assert.NoError(t, err)
+
+	entrySize := int64(rootCacheHeaderSize + common.HashLength)
+	cache.WithMaxSize(2 * entrySize)
+
+	// expired temp file left by crashed process
+	tmpFile := filepath.Join(dir, "entry.123.tmp")
+	assert.NoError(t, os.WriteFile(tmpFile, []byte{1}, 0644))
+	expired := time.Now().Add(-2 * rootCacheTmpExpiration)
+	assert.NoError(t, os.Chtimes(tmpFile, expired, expired))
+
+	var files []*File
+	for i := 0; i < 3; i++ {
+		file := openWithRootCache(t, createTestFile(t, createTestData(100+i)), cache)
+		defer file.Close()
+
+		_, err = file.MerkleRoot()
+		assert.NoError(t, err)
+
+		// least recently used at first
+		entry, err := cache.entryPath(wholeFileKey(file))
+		assert.NoError(t, err)
+		modTime := time.Now().Add(time.Duration(i-10) * time.Minute)
+		assert.NoError(t, os.Chtimes(entry, modTime, modTime))
+
+		files = append(files, file)
+	}
+
+	// the least recently used one evicted once the third one cached
+	assert.NoError(t, cache.evict())
+
+	_, ok := cachedRoots(cache, wholeFileKey(files[0]))
+	assert.False(t, ok)
+	_, ok = cachedRoots(cache, wholeFileKey(files[1]))
+	assert.True(t, ok)
+	_, ok = cachedRoots(cache, wholeFileKey(files[2]))
+	assert.True(t, ok)
+
+	_, err = os.Stat(tmpFile)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,71 @@
+package file
+
+import (
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultSpoolMemoryLimit is the default max size of data buffered in memory when spooling.
+const DefaultSpoolMemoryLimit = int64(64 * 1024 * 1024)
+
+// Spool reads all data from the specified reader of unknown length, e.g. stdin, so that data
+// could be read at random offsets to upload.
+//
+// Data is buffered in memory if not larger than memoryLimit, otherwise spooled to a temp file,
+// which will be removed when the returned file closed.
+func Spool(reader io.Reader, memoryLimit int64) (*File, error) {
+	if memoryLimit <= 0 {
+		memoryLimit = DefaultSpoolMemoryLimit
+	}
+
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(reader, memoryLimit+1))
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to read data")
+	}
+
+	// all data buffered in memory
+	if n <= memoryLimit {
+		return OpenReaderAt(bytes.NewReader(buf.Bytes()), n)
+	}
+
+	tmpFile, err := os.CreateTemp("", "ionian-spool-*")
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to create temp file")
+	}
+
+	file, err := spoolToFile(tmpFile, io.MultiReader(&buf, reader))
+	if err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func spoolToFile(tmpFile *os.File, reader io.Reader) (*File, error) {
+	if _, err := io.Copy(tmpFile, reader); err != nil {
+		return nil, errors.WithMessagef(err, "Failed to write data to %v", tmpFile.Name())
+	}
+
+	info, err := tmpFile.Stat()
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to stat temp file")
+	}
+
+	return &File{
+		FileInfo:   info,
+		underlying: tmpFile,
+		closer: func() error {
+			if err := tmpFile.Close(); err != nil {
+				return err
+			}
+
+			return os.Remove(tmpFile.Name())
+		},
+	}, nil
+}
@@ -0,0 +1,179 @@
+package file
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// latencyCongestionFactor indicates a storage node is congested if latency exceeds the factor
+	// of the min latency observed, in which case in-flight requests will be decreased.
+	latencyCongestionFactor = 2
+
+	// congestionDecreaseFactor is the factor to decrease in-flight requests in case of congestion.
+	congestionDecreaseFactor = 0.75
+)
+
+// concurrencyLimiter limits the number of in-flight requests to a storage node. In adaptive mode,
+// the limit is adjusted in AIMD (additive increase, multiplicative decrease) way based on observed
+// latency and errors, and always ranges in [1, max].
+type concurrencyLimiter struct {
+	max      int
+	adaptive bool
+
+	mu         sync.Mutex
+	limit      float64       // fractional to increase additively
+	inflight   int           // number of in-flight requests
+	minLatency time.Duration // min latency observed as baseline
+	released   chan struct{} // closed once any request released
+}
+
+func newConcurrencyLimiter(max int, adaptive bool) *concurrencyLimiter {
+	if max <= 0 {
+		max = 1
+	}
+
+	limit := float64(max)
+	if adaptive {
+		// start with a single request, and increase once succeeded
+		limit = 1
+	}
+
+	return &concurrencyLimiter{
+		max:      max,
+		adaptive: adaptive,
+		limit:    limit,
+		released: make(chan struct{}),
+	}
+}
+
+// current returns the current limit of in-flight requests.
+func (limiter *concurrencyLimiter) current() int {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	return int(limiter.limit)
+}
+
+// acquire waits until the number of in-flight requests below limit, or the specified context is done.
+func (limiter *concurrencyLimiter) acquire(ctx context.Context) error {
+	for {
+		limiter.mu.Lock()
+
+		if limiter.inflight < int(limiter.limit) {
+			limiter.inflight++
+			limiter.mu.Unlock()
+			return nil
+		}
+
+		released := limiter.released
+		limiter.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-released:
+		}
+	}
+}
+
+// release releases the in-flight request with the observed latency and error, which is used to
+// adjust the limit in adaptive mode.
+func (limiter *concurrencyLimiter) release(latency time.Duration, err error) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	limiter.inflight--
+
+	if limiter.adaptive {
+		limiter.adjust(latency, err)
+	}
+
+	// wake up all waiting requests
+	close(limiter.released)
+	limiter.released = make(chan struct{})
+}
+
+func (limiter *concurrencyLimiter) adjust(latency time.Duration, err error) {
+	// request canceled by caller
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+
+	if err != nil {
+		limiter.decrease(0.5)
+		return
+	}
+
+	if limiter.minLatency == 0 || latency < limiter.minLatency {
+		limiter.minLatency = latency
+	}
+
+	if latency > limiter.minLatency*latencyCongestionFactor {
+		limiter.decrease(congestionDecreaseFactor)
+		return
+	}
+
+	// increase by 1 once all in-flight requests of the current limit succeeded
+	if limiter.limit += 1 / limiter.limit; limiter.limit > float64(limiter.max) {
+		limiter.limit = float64(limiter.max)
+	}
+}
+
+func (limiter *concurrencyLimiter) decrease(factor float64) {
+	if limiter.limit *= factor; limiter.limit < 1 {
+		limiter.limit = 1
+	}
+}
+
+// bandwidthLimiter limits the download bandwidth in token bucket way, which allows to burst
+// data of a second. Note, nil limiter indicates unlimited.
+type bandwidthLimiter struct {
+	rate float64 // bytes per second
+
+	mu     sync.Mutex
+	tokens float64 // available bytes, negative for bytes reserved in advance
+	last   time.Time
+}
+
+// newBandwidthLimiter creates a limiter of the specified bytes per second, or nil if unlimited.
+func newBandwidthLimiter(bytesPerSecond int64) *bandwidthLimiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+
+	return &bandwidthLimiter{
+		rate:   float64(bytesPerSecond),
+		tokens: float64(bytesPerSecond),
+		last:   time.Now(),
+	}
+}
+
+// wait reserves n bytes, and waits until the bytes available or the specified context is done.
+func (limiter *bandwidthLimiter) wait(ctx context.Context, n int) error {
+	if limiter == nil {
+		return nil
+	}
+
+	limiter.mu.Lock()
+
+	now := time.Now()
+	if limiter.tokens += now.Sub(limiter.last).Seconds() * limiter.rate; limiter.tokens > limiter.rate {
+		limiter.tokens = limiter.rate
+	}
+	limiter.last = now
+
+	limiter.tokens -= float64(n)
+	tokens := limiter.tokens
+
+	limiter.mu.Unlock()
+
+	if tokens >= 0 {
+		return nil
+	}
+
+	return sleep(ctx, time.Duration(-tokens/limiter.rate*float64(time.Second)))
+}
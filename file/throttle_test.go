@@ -0,0 +1,96 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	limiter := newConcurrencyLimiter(2, false)
+	assert.Equal(t, 2, limiter.current())
+
+	assert.NoError(t, limiter.acquire(context.Background()))
+	assert.NoError(t, limiter.acquire(context.Background()))
+
+	// blocked until any request released
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter.acquire(ctx), context.DeadlineExceeded)
+
+	go limiter.release(time.Millisecond, nil)
+	assert.NoError(t, limiter.acquire(context.Background()))
+
+	// limit not changed in non-adaptive mode
+	limiter.release(time.Millisecond, errors.New("error"))
+	assert.Equal(t, 2, limiter.current())
+}
+
+func TestConcurrencyLimiterAdaptive(t *testing.T) {
+	limiter := newConcurrencyLimiter(4, true)
+	assert.Equal(t, 1, limiter.current())
+
+	// increase additively
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, limiter.acquire(context.Background()))
+		limiter.release(10*time.Millisecond, nil)
+	}
+	assert.Equal(t, 4, limiter.current())
+
+	// decrease multiplicatively in case of error
+	assert.NoError(t, limiter.acquire(context.Background()))
+	limiter.release(10*time.Millisecond, errors.New("error"))
+	assert.Equal(t, 2, limiter.current())
+
+	// ignore canceled request
+	assert.NoError(t, limiter.acquire(context.Background()))
+	limiter.release(10*time.Millisecond, context.Canceled)
+	assert.Equal(t, 2, limiter.current())
+
+	// decrease in case of latency increased significantly
+	assert.NoError(t, limiter.acquire(context.Background()))
+	limiter.release(50*time.Millisecond, nil)
+	assert.Equal(t, 1, limiter.current())
+}
+
+func TestBandwidthLimiter(t *testing.T) {
+	assert.NoError(t, (*bandwidthLimiter)(nil).wait(context.Background(), 1024))
+
+	limiter := newBandwidthLimiter(10000)
+
+	// burst for data of a second
+	start := time.Now()
+	assert.NoError(t, limiter.wait(context.Background(), 10000))
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+
+	assert.NoError(t, limiter.wait(context.Background(), 2000))
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+
+	// terminate once context done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, limiter.wait(ctx, 10000), context.Canceled)
+}
+
+func TestDownloadConcurrency(t *testing.T) {
+	data := createTestData(9*DefaultSegmentSize + 100)
+
+	mock1, client1 := newMockNode(t, data)
+	_, client2 := newMockNode(t, data)
+
+	downloader := NewDownloader(client1, client2).
+		WithConcurrency(4, true).
+		WithBandwidth(100 * 1024 * 1024)
+
+	filename := filepath.Join(t.TempDir(), "data")
+	assert.NoError(t, downloader.DownloadContext(context.Background(), mock1.tree.Root().Hex(), filename, true))
+
+	downloaded, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, data, downloaded)
+}
@@ -0,0 +1,169 @@
+package file
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/common/parallel"
+	"github.com/Ionian-Web3-Storage/ionian-client/file/merkle"
+	"github.com/Ionian-Web3-Storage/ionian-client/node"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// SegmentUploader uploads segments to all storage nodes in parallel, since file will be finalized
+// only if all storage nodes hold the whole file.
+type SegmentUploader struct {
+	pool *nodePool
+	file *File
+	tree *merkle.Tree
+
+	routinesPerNode int
+	progress        *progressReporter
+	journal         *uploadJournal
+
+	segmentOffset uint64
+	numChunks     uint64
+	numSegments   uint64
+}
+
+func NewSegmentUploader(clients []*node.Client, file *File, tree *merkle.Tree, segmentOffset uint64, option UploadOption) *SegmentUploader {
+	routinesPerNode := option.SegmentsPerNode
+	if routinesPerNode == 0 {
+		routinesPerNode = 1
+	}
+
+	return &SegmentUploader{
+		pool: newNodePool(clients, option.Retry).withConcurrency(int(routinesPerNode), false),
+		file: file,
+		tree: tree,
+
+		routinesPerNode: int(routinesPerNode),
+
+		segmentOffset: segmentOffset,
+		numChunks:     file.NumChunks(),
+		numSegments:   file.NumSegments(),
+	}
+}
+
+// Upload uploads every segment to all storage nodes in parallel, with at most routinesPerNode
+// in-flight segments for each storage node.
+func (uploader *SegmentUploader) Upload(ctx context.Context) error {
+	if uploader.segmentOffset >= uploader.numSegments {
+		return nil
+	}
+
+	uploader.progress.report(PhaseUploading, uploader.segmentOffset)
+
+	// each task uploads a segment to a storage node
+	numNodes := len(uploader.pool.clients)
+	numTasks := int(uploader.numSegments-uploader.segmentOffset) * numNodes
+	numRoutines := numNodes * uploader.routinesPerNode
+	bufSize := numRoutines * 2
+	if bufSize < minBufSize {
+		bufSize = minBufSize
+	}
+
+	return parallel.SerialContext(ctx, uploader, numTasks, numRoutines, bufSize)
+}
+
+// task returns the segment index and storage node of specified task.
+func (uploader *SegmentUploader) task(task int) (uint64, int) {
+	numNodes := len(uploader.pool.clients)
+	return uploader.segmentOffset + uint64(task/numNodes), task % numNodes
+}
+
+// ParallelDoContext implements the parallel.ContextInterface interface.
+func (uploader *SegmentUploader) ParallelDoContext(ctx context.Context, routine, task int) (interface{}, error) {
+	segIndex, nodeIndex := uploader.task(task)
+
+	// already uploaded before process crashed
+	if uploader.journal.Acked(segIndex) {
+		return nil, nil
+	}
+
+	segment, err := uploader.readSegment(segIndex)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "Failed to read segment %v", segIndex)
+	}
+
+	segWithProof := node.SegmentWithProof{
+		Root:     uploader.tree.Root(),
+		Data:     segment,
+		Index:    segIndex,
+		Proof:    uploader.tree.ProofAt(int(segIndex)),
+		FileSize: uint64(uploader.file.Size()),
+	}
+
+	// upload to the storage node, and retry on the same node if failed
+	nodeUrl := uploader.pool.clients[nodeIndex].URL()
+	err = uploader.pool.callNode(ctx, nodeIndex, func(client *node.Client) error {
+		_, err := client.Ionian().UploadSegmentContext(ctx, segWithProof)
+		return err
+	})
+
+	if err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"node":    nodeUrl,
+			"segment": fmt.Sprintf("%v/%v", segIndex, uploader.numSegments),
+		}).Error("Failed to upload segment")
+
+		return nil, errors.WithMessagef(err, "Failed to upload segment %v to node %v", segIndex, nodeUrl)
+	}
+
+	return segment, nil
+}
+
+// ParallelCollect implements the parallel.ContextInterface interface.
+func (uploader *SegmentUploader) ParallelCollect(result *parallel.Result) error {
+	segIndex, nodeIndex := uploader.task(result.Task)
+
+	// results collected in order, so segment uploaded to all storage nodes once collected from the last node
+	if nodeIndex < len(uploader.pool.clients)-1 {
+		return nil
+	}
+
+	uploader.journal.Ack(segIndex)
+	uploader.progress.report(PhaseUploading, segIndex+1)
+
+	// segment skipped if already uploaded
+	segment, ok := result.Value.([]byte)
+
+	if ok && logrus.IsLevelEnabled(logrus.DebugLevel) {
+		chunkIndex := segIndex * DefaultSegmentMaxChunks
+
+		logrus.WithFields(logrus.Fields{
+			"total":      uploader.numSegments,
+			"index":      segIndex,
+			"chunkStart": chunkIndex,
+			"chunkEnd":   chunkIndex + uint64(len(segment))/DefaultChunkSize,
+			"root":       segmentRoot(segment),
+		}).Debug("Segment uploaded")
+	}
+
+	return nil
+}
+
+// readSegment reads the segment data at the specified index, without rear padding data.
+func (uploader *SegmentUploader) readSegment(segIndex uint64) ([]byte, error) {
+	iter := NewSegmentIterator(uploader.file.underlying, uploader.file.Size(), int64(segIndex*DefaultSegmentSize), true)
+
+	ok, err := iter.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, errors.New("segment index out of bound")
+	}
+
+	segment := iter.Current()
+
+	// skip upload rear padding data
+	startIndex := segIndex * DefaultSegmentMaxChunks
+	if startIndex+uint64(len(segment))/DefaultChunkSize > uploader.numChunks {
+		segment = segment[:DefaultChunkSize*(uploader.numChunks-startIndex)]
+	}
+
+	return segment, nil
+}
@@ -1,9 +1,12 @@
 package file
 
 import (
+	"context"
+	"io"
 	"time"
 
 	"github.com/Ionian-Web3-Storage/ionian-client/contract"
+	"github.com/Ionian-Web3-Storage/ionian-client/file/encryption"
 	"github.com/Ionian-Web3-Storage/ionian-client/file/merkle"
 	"github.com/Ionian-Web3-Storage/ionian-client/node"
 	"github.com/ethereum/go-ethereum/common"
@@ -16,34 +19,76 @@ import (
 const smallFileSizeThreshold = int64(256 * 1024)
 
 type UploadOption struct {
-	Tags  []byte // for kv operations
-	Force bool   // for kv to upload same file
+	Tags            []byte // for kv operations
+	Force           bool   // for kv to upload same file
+	SegmentsPerNode uint   // number of segments to upload in parallel for each storage node, default 1
+
+	Retry RetryOption // retry policy to upload segments
+
+	Progress ProgressListener // listener to receive progress events
+
+	Encryption *encryption.Key // key to encrypt file before upload, nil means no encryption
+
+	// PartSize is the max size of each part to split large file, default DefaultPartSize.
+	// Each part is uploaded with a separate submission, along with a parts manifest.
+	PartSize int64
+
+	HashWorkers int        // number of routines to calculate file merkle root, default runtime.NumCPU()
+	RootCache   *RootCache // cache of segment roots for unchanged files, nil means no cache
+
+	nonces      *contract.NonceManager   // allocates nonces to send transactions in batch, nil means pending nonce
+	onSubmitted func(txHash common.Hash) // called once transaction sent or recorded in journal
+}
+
+// DefaultPartSize is the default part size to split large file.
+const DefaultPartSize = MaxSubmissionSize
+
+// partSize returns the part size to split large file, which should be multiple of segment size
+// and not exceed MaxSubmissionSize.
+func (opt *UploadOption) partSize() (int64, error) {
+	if opt.PartSize == 0 {
+		return DefaultPartSize, nil
+	}
+
+	if opt.PartSize < 0 || opt.PartSize > MaxSubmissionSize || opt.PartSize%DefaultSegmentSize != 0 {
+		return 0, errors.Errorf("Invalid part size %v, should be multiple of segment size and not exceed %v", opt.PartSize, MaxSubmissionSize)
+	}
+
+	return opt.PartSize, nil
 }
 
 type Uploader struct {
-	flow   *contract.FlowExt
-	client *node.IonianClient
+	flow    *contract.FlowExt
+	clients []*node.Client
 }
 
-func NewUploader(flow *contract.FlowExt, client *node.Client) *Uploader {
-	return &Uploader{
-		flow:   flow,
-		client: client.Ionian(),
+// NewUploader creates an uploader to upload file to the specified storage nodes.
+// Note, segments will be uploaded to multiple storage nodes in parallel.
+func NewUploader(flow *contract.FlowExt, clients ...*node.Client) *Uploader {
+	if len(clients) == 0 {
+		panic("storage node not specified")
 	}
-}
 
-func NewUploaderLight(client *node.Client) *Uploader {
 	return &Uploader{
-		client: client.Ionian(),
+		flow:    flow,
+		clients: clients,
 	}
 }
 
+func NewUploaderLight(clients ...*node.Client) *Uploader {
+	return NewUploader(nil, clients...)
+}
+
 func (uploader *Uploader) Upload(filename string, option ...UploadOption) error {
-	var opt UploadOption
-	if len(option) > 0 {
-		opt = option[0]
-	}
+	return uploader.UploadContext(context.Background(), filename, option...)
+}
 
+// UploadContext uploads file to storage nodes, and terminates once the specified context is done.
+//
+// Note, upload progress is recorded in a journal next to the file, so that a rerun after
+// process crashed will continue where it stopped, without submitting log entry again or
+// uploading the acknowledged segments again. The journal will be removed once completed.
+func (uploader *Uploader) UploadContext(ctx context.Context, filename string, option ...UploadOption) error {
 	// Open file to upload
 	file, err := Open(filename)
 	if err != nil {
@@ -51,6 +96,69 @@ func (uploader *Uploader) Upload(filename string, option ...UploadOption) error
 	}
 	defer file.Close()
 
+	_, err = uploader.upload(ctx, file, filename+JournalSuffix, option...)
+	return err
+}
+
+// UploadReaderAt uploads data from the specified reader of given size, e.g. in-memory buffer.
+func (uploader *Uploader) UploadReaderAt(ctx context.Context, reader io.ReaderAt, size int64, option ...UploadOption) error {
+	file, err := OpenReaderAt(reader, size)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to open data")
+	}
+
+	_, err = uploader.upload(ctx, file, "", option...)
+	return err
+}
+
+// UploadReader uploads data from the specified reader of unknown length, e.g. stdin. Note, data
+// will be spooled in memory or a temp file at first, see Spool for more details.
+func (uploader *Uploader) UploadReader(ctx context.Context, reader io.Reader, option ...UploadOption) error {
+	file, err := Spool(reader, DefaultSpoolMemoryLimit)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to spool data")
+	}
+	defer file.Close()
+
+	_, err = uploader.upload(ctx, file, "", option...)
+	return err
+}
+
+// upload uploads the specified file and returns the file merkle root. If journalPath specified,
+// upload progress will be recorded in journal to resume upload later.
+func (uploader *Uploader) upload(ctx context.Context, file *File, journalPath string, option ...UploadOption) (common.Hash, error) {
+	var opt UploadOption
+	if len(option) > 0 {
+		opt = option[0]
+	}
+
+	partSize, err := opt.partSize()
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	if opt.Encryption != nil {
+		encrypted, err := encryptFile(file, opt.Encryption, journalPath)
+		if err != nil {
+			return common.Hash{}, err
+		}
+
+		opt.Encryption = nil
+
+		root, err := uploader.upload(ctx, encrypted, journalPath, opt)
+		if len(journalPath) > 0 && (err == nil || errors.Is(err, ErrFileAlreadyExists)) {
+			removeEncryptionHeader(journalPath)
+		}
+
+		return root, err
+	}
+
+	file.WithHashWorkers(opt.HashWorkers).WithRootCache(opt.RootCache)
+
+	if file.Size() > partSize {
+		return uploader.uploadParts(ctx, file, partSize, journalPath, opt)
+	}
+
 	logrus.WithFields(logrus.Fields{
 		"name":     file.Name(),
 		"size":     file.Size(),
@@ -59,13 +167,94 @@ func (uploader *Uploader) Upload(filename string, option ...UploadOption) error
 	}).Info("File prepared to upload")
 
 	// Calculate file merkle root.
+	reporter := newProgressReporter(opt.Progress, common.Hash{}, file.Size())
+	reporter.report(PhaseHashing, 0)
+
 	tree, err := file.MerkleTree()
 	if err != nil {
-		return errors.WithMessage(err, "Failed to create file merkle tree")
+		return common.Hash{}, errors.WithMessage(err, "Failed to create file merkle tree")
 	}
 	logrus.WithField("root", tree.Root()).Info("File merkle root calculated")
 
-	info, err := uploader.client.GetFileInfo(tree.Root())
+	reporter.root = tree.Root()
+
+	journal := openJournal(journalPath, tree.Root(), file.Size())
+
+	if err = uploader.uploadByTree(ctx, file, tree, opt, reporter, journal); err != nil {
+		if errors.Is(err, ErrFileAlreadyExists) {
+			journal.Remove()
+		} else {
+			journal.Close()
+		}
+
+		return tree.Root(), err
+	}
+
+	journal.Remove()
+
+	reporter.report(PhaseCompleted, file.NumSegments())
+
+	return tree.Root(), nil
+}
+
+// encryptFile encrypts the specified file with key. Random salt is used for every encryption, so
+// if journalPath specified, the encryption header is recorded in journal and reused for the
+// unchanged file, so that upload could be resumed with the same encrypted data and merkle root.
+func encryptFile(file *File, key *encryption.Key, journalPath string) (*File, error) {
+	var header *encryption.Header
+	if len(journalPath) > 0 && len(file.path) > 0 {
+		header = loadEncryptionHeader(journalPath, file.FileInfo)
+	}
+
+	var encrypted *encryption.Reader
+	var err error
+
+	if header != nil {
+		encrypted, err = encryption.NewReaderWithHeader(key, header, file.underlying, file.Size())
+	}
+
+	if header == nil || err != nil {
+		if err != nil {
+			logrus.WithError(err).WithField("journal", journalPath).Warn("Failed to reuse encryption header in journal")
+		}
+
+		if encrypted, err = encryption.NewReader(key, file.underlying, file.Size()); err != nil {
+			return nil, errors.WithMessage(err, "Failed to encrypt file")
+		}
+
+		if len(journalPath) > 0 && len(file.path) > 0 {
+			if err = saveEncryptionHeader(journalPath, file.FileInfo, encrypted.Header()); err != nil {
+				logrus.WithError(err).WithField("journal", journalPath).Warn("Failed to record encryption header in journal")
+			}
+		}
+	}
+
+	result, err := OpenReaderAt(encrypted, encrypted.Size())
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to open encrypted file")
+	}
+
+	return result, nil
+}
+
+// openJournal opens the upload journal if path specified. Upload journal is optional, so only
+// logs a warning if failed to open journal.
+func openJournal(path string, root common.Hash, size int64) *uploadJournal {
+	if len(path) == 0 {
+		return nil
+	}
+
+	journal, err := openUploadJournal(path, root, size)
+	if err != nil {
+		logrus.WithError(err).WithField("journal", path).Warn("Failed to open upload journal")
+		return nil
+	}
+
+	return journal
+}
+
+func (uploader *Uploader) uploadByTree(ctx context.Context, file *File, tree *merkle.Tree, opt UploadOption, reporter *progressReporter, journal *uploadJournal) error {
+	info, err := uploader.clients[0].Ionian().GetFileInfoContext(ctx, tree.Root())
 	if err != nil {
 		return errors.WithMessage(err, "Failed to get file info from storage node")
 	}
@@ -80,11 +269,11 @@ func (uploader *Uploader) Upload(filename string, option ...UploadOption) error
 	// already finalized
 	if info != nil && info.Finalized {
 		if !opt.Force {
-			return errors.New("File already exists on Ionian network")
+			return ErrFileAlreadyExists
 		}
 
 		// Allow to upload duplicated file for KV scenario
-		if err = uploader.uploadDuplicatedFile(file, opt.Tags, tree.Root()); err != nil {
+		if err = uploader.uploadDuplicatedFile(ctx, file, opt, tree.Root(), reporter, journal); err != nil {
 			return errors.WithMessage(err, "Failed to upload duplicated file")
 		}
 
@@ -94,8 +283,8 @@ func (uploader *Uploader) Upload(filename string, option ...UploadOption) error
 	// Log entry unavailable on storage node yet.
 	segNum := uint64(0)
 	if info == nil {
-		// Append log on blockchain
-		if _, err = uploader.submitLogEntry(file, opt.Tags); err != nil {
+		// Append log on blockchain, unless already submitted before process crashed
+		if _, err = uploader.submitLogEntry(ctx, file, opt, reporter, journal); err != nil {
 			return errors.WithMessage(err, "Failed to submit log entry")
 		}
 
@@ -106,141 +295,220 @@ func (uploader *Uploader) Upload(filename string, option ...UploadOption) error
 			logrus.Info("Upload small file immediately")
 		} else {
 			// Wait for storage node to retrieve log entry from blockchain
-			if err = uploader.waitForLogEntry(tree.Root(), false); err != nil {
+			reporter.report(PhaseWaitingLogEntry, 0)
+			if err = uploader.waitForLogEntry(ctx, tree.Root(), false); err != nil {
 				return errors.WithMessage(err, "Failed to check if log entry available on storage node")
 			}
-			info, err = uploader.client.GetFileInfo(tree.Root())
-			if err != nil {
+			if segNum, err = uploader.queryUploadedSegNum(ctx, tree.Root()); err != nil {
 				return errors.WithMessage(err, "Failed to get file info from storage node after waitForLogEntry.")
 			}
-			segNum = info.UploadedSegNum
 		}
 	}
 
 	// Upload file to storage node
-	if err = uploader.uploadFile(file, tree, segNum); err != nil {
+	if err = uploader.uploadFile(ctx, file, tree, segNum, opt, reporter, journal); err != nil {
 		return errors.WithMessage(err, "Failed to upload file")
 	}
 
 	// Wait for transaction finality
-	if err = uploader.waitForLogEntry(tree.Root(), true); err != nil {
+	reporter.report(PhaseFinalizing, file.NumSegments())
+	if err = uploader.waitForLogEntry(ctx, tree.Root(), true); err != nil {
 		return errors.WithMessage(err, "Failed to wait for transaction finality on storage node")
 	}
 
 	return nil
 }
 
-func (uploader *Uploader) submitLogEntry(file *File, tags []byte) (*types.Receipt, error) {
+// submitLogEntry submits log entry on blockchain and returns the submission index. If the
+// transaction has already been sent according to journal, it only waits for the receipt.
+func (uploader *Uploader) submitLogEntry(ctx context.Context, file *File, opt UploadOption, reporter *progressReporter, journal *uploadJournal) (uint64, error) {
+	if txSeq := journal.TxSeq(); txSeq != nil {
+		logrus.WithField("txSeq", *txSeq).Info("Log entry already submitted")
+
+		if txHash := journal.TxHash(); txHash != nil && opt.onSubmitted != nil {
+			opt.onSubmitted(*txHash)
+		}
+
+		return *txSeq, nil
+	}
+
+	reporter.report(PhaseSubmitting, 0)
+
+	var hash common.Hash
+
+	if txHash := journal.TxHash(); txHash != nil {
+		hash = *txHash
+		logrus.WithField("hash", hash.Hex()).Info("Transaction already sent to append log entry")
+	} else {
+		var err error
+		if hash, err = uploader.sendLogEntry(ctx, file, opt.Tags, opt.nonces); err != nil {
+			return 0, err
+		}
+
+		journal.RecordTx(hash)
+	}
+
+	if opt.onSubmitted != nil {
+		opt.onSubmitted(hash)
+	}
+
+	return uploader.waitForSubmission(ctx, hash, journal)
+}
+
+// sendLogEntry sends transaction to append log entry on blockchain without waiting for receipt.
+// If nonces not specified, the pending nonce will be used.
+func (uploader *Uploader) sendLogEntry(ctx context.Context, file *File, tags []byte, nonces *contract.NonceManager) (common.Hash, error) {
 	// Construct submission
 	flow := NewFlow(file, tags)
 	submission, err := flow.CreateSubmission()
 	if err != nil {
-		return nil, errors.WithMessage(err, "Failed to create flow submission")
+		return common.Hash{}, errors.WithMessage(err, "Failed to create flow submission")
 	}
 
 	// Submit log entry to smart contract.
-	hash, err := uploader.flow.SubmitExt(*submission)
+	var hash common.Hash
+	if nonces == nil {
+		hash, err = uploader.flow.SubmitExtContext(ctx, *submission)
+	} else {
+		var nonce uint64
+		if nonce, err = nonces.Next(); err != nil {
+			return common.Hash{}, err
+		}
+
+		if hash, err = uploader.flow.SubmitExtWithNonce(ctx, *submission, nonce); err != nil {
+			// nonce may be used or not, so fetch from blockchain again
+			nonces.Reset()
+		}
+	}
+
 	if err != nil {
-		return nil, errors.WithMessage(err, "Failed to send transaction to append log entry")
+		return common.Hash{}, errors.WithMessage(err, "Failed to send transaction to append log entry")
 	}
 
 	logrus.WithField("hash", hash.Hex()).Info("Succeeded to send transaction to append log entry")
 
-	// Wait for successful execution
-	return uploader.flow.WaitForReceipt(hash, true)
+	return hash, nil
 }
 
-// Wait for log entry ready on storage node.
-func (uploader *Uploader) waitForLogEntry(root common.Hash, finalityRequired bool) error {
-	logrus.WithFields(logrus.Fields{
-		"root":     root,
-		"finality": finalityRequired,
-	}).Info("Wait for log entry on storage node")
+// waitForSubmission waits for the transaction executed, and returns the submission index.
+func (uploader *Uploader) waitForSubmission(ctx context.Context, hash common.Hash, journal *uploadJournal) (uint64, error) {
+	receipt, err := uploader.flow.WaitForReceiptContext(ctx, hash, true)
+	if err != nil {
+		return 0, errors.WithMessage(err, "Failed to wait for transaction receipt")
+	}
 
-	for {
-		time.Sleep(time.Second)
+	txSeq, err := uploader.parseSubmissionIndex(receipt)
+	if err != nil {
+		return 0, errors.WithMessage(err, "Failed to parse submission from receipt")
+	}
 
-		info, err := uploader.client.GetFileInfo(root)
-		if err != nil {
-			return errors.WithMessage(err, "Failed to get file info from storage node")
-		}
+	journal.RecordTxSeq(txSeq)
 
-		// log entry unavailable yet
-		if info == nil {
+	return txSeq, nil
+}
+
+// parseSubmissionIndex parses the submission index from event logs in receipt.
+func (uploader *Uploader) parseSubmissionIndex(receipt *types.Receipt) (uint64, error) {
+	for _, v := range receipt.Logs {
+		if len(v.Topics) == 0 || v.Topics[0] != submissionEventHash {
 			continue
 		}
 
-		if finalityRequired && !info.Finalized {
-			continue
+		log := contract.ConvertToGethLog(v)
+
+		submission, err := uploader.flow.ParseSubmission(*log)
+		if err != nil {
+			return 0, err
 		}
 
-		break
+		return submission.SubmissionIndex.Uint64(), nil
 	}
 
-	return nil
+	return 0, errors.New("Submission event not found")
 }
 
-// TODO error tolerance
-func (uploader *Uploader) uploadFile(file *File, tree *merkle.Tree, segIndex uint64) error {
-	logrus.WithField("segIndex", segIndex).Info("Begin to upload file")
+// Wait for log entry ready on all storage nodes.
+func (uploader *Uploader) waitForLogEntry(ctx context.Context, root common.Hash, finalityRequired bool) error {
+	logrus.WithFields(logrus.Fields{
+		"root":     root,
+		"finality": finalityRequired,
+	}).Info("Wait for log entry on storage node")
 
-	iter := NewSegmentIterator(file.underlying, file.Size(), int64(segIndex*DefaultSegmentSize), true)
+	for _, client := range uploader.clients {
+		for {
+			ready, err := logEntryReady(ctx, client, root, finalityRequired)
+			if err != nil {
+				return err
+			}
 
-	for {
-		ok, err := iter.Next()
-		if err != nil {
-			return errors.WithMessage(err, "Failed to read segment")
-		}
+			if ready {
+				break
+			}
 
-		if !ok {
-			break
+			if err = sleep(ctx, time.Second); err != nil {
+				return err
+			}
 		}
+	}
 
-		segment := iter.Current()
-		proof := tree.ProofAt(int(segIndex))
+	return nil
+}
 
-		// Skip upload rear padding data
-		numChunks := file.NumChunks()
-		startIndex := segIndex * DefaultSegmentMaxChunks
-		allDataUploaded := false
-		if startIndex >= numChunks {
-			// file real data already uploaded
-			break
-		} else if startIndex+uint64(len(segment))/DefaultChunkSize >= numChunks {
-			// last segment has real data
-			expectedLen := DefaultChunkSize * int(numChunks-startIndex)
-			segment = segment[:expectedLen]
-			allDataUploaded = true
-		}
+// logEntryReady polls the storage node once to check whether log entry is available, or finalized
+// if required. Retryable errors are ignored, so as to poll again later.
+func logEntryReady(ctx context.Context, client *node.Client, root common.Hash, finalityRequired bool) (bool, error) {
+	info, err := client.Ionian().GetFileInfoContext(ctx, root)
+	if node.IsRetryableError(err) {
+		logrus.WithError(err).WithField("node", client.URL()).Warn("Failed to get file info from storage node, retry later")
+		return false, nil
+	}
 
-		segWithProof := node.SegmentWithProof{
-			Root:     tree.Root(),
-			Data:     segment,
-			Index:    segIndex,
-			Proof:    proof,
-			FileSize: uint64(file.Size()),
-		}
+	if err != nil {
+		return false, errors.WithMessagef(err, "Failed to get file info from storage node %v", client.URL())
+	}
+
+	// log entry unavailable yet
+	if info == nil {
+		return false, nil
+	}
+
+	return !finalityRequired || info.Finalized, nil
+}
 
-		if _, err = uploader.client.UploadSegment(segWithProof); err != nil {
-			return errors.WithMessage(err, "Failed to upload segment")
+// queryUploadedSegNum returns the minimum number of uploaded segments among all storage nodes.
+func (uploader *Uploader) queryUploadedSegNum(ctx context.Context, root common.Hash) (uint64, error) {
+	var segNum uint64
+
+	for i, client := range uploader.clients {
+		info, err := client.Ionian().GetFileInfoContext(ctx, root)
+		if err != nil {
+			return 0, errors.WithMessagef(err, "Failed to get file info from storage node %v", client.URL())
 		}
 
-		if logrus.IsLevelEnabled(logrus.DebugLevel) {
-			chunkIndex := segIndex * DefaultSegmentMaxChunks
-			logrus.WithFields(logrus.Fields{
-				"total":      file.NumSegments(),
-				"index":      segIndex,
-				"chunkStart": chunkIndex,
-				"chunkEnd":   chunkIndex + uint64(len(segment))/DefaultChunkSize,
-				"root":       segmentRoot(segment),
-			}).Debug("Segment uploaded")
+		if info == nil {
+			return 0, errors.Errorf("Log entry not found on storage node %v", client.URL())
 		}
 
-		if allDataUploaded {
-			break
+		if i == 0 || info.UploadedSegNum < segNum {
+			segNum = info.UploadedSegNum
 		}
+	}
+
+	return segNum, nil
+}
+
+func (uploader *Uploader) uploadFile(ctx context.Context, file *File, tree *merkle.Tree, segIndex uint64, opt UploadOption, reporter *progressReporter, journal *uploadJournal) error {
+	logrus.WithFields(logrus.Fields{
+		"segIndex": segIndex,
+		"nodes":    len(uploader.clients),
+	}).Info("Begin to upload file")
+
+	su := NewSegmentUploader(uploader.clients, file, tree, segIndex, opt)
+	su.progress = reporter
+	su.journal = journal
 
-		segIndex++
+	if err := su.Upload(ctx); err != nil {
+		return err
 	}
 
 	logrus.Info("Completed to upload file")
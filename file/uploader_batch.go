@@ -0,0 +1,230 @@
+package file
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/contract"
+	"github.com/Ionian-Web3-Storage/ionian-client/file/merkle"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Status of file in batch upload.
+const (
+	BatchStatusUploaded = "uploaded"
+	BatchStatusExists   = "exists"
+	BatchStatusFailed   = "failed"
+)
+
+// BatchUploadResult is the upload result of a file in batch.
+type BatchUploadResult struct {
+	File   string       `json:"file"`
+	Root   common.Hash  `json:"root"`
+	TxHash *common.Hash `json:"txHash,omitempty"`
+	Status string       `json:"status"`
+	Error  string       `json:"error,omitempty"`
+}
+
+func (result *BatchUploadResult) fail(err error) {
+	result.Status = BatchStatusFailed
+	result.Error = err.Error()
+}
+
+// batchUploadTask is passed from the submission stage to the upload stage in batch upload.
+type batchUploadTask struct {
+	index    int
+	filename string
+	file     *File
+	tree     *merkle.Tree
+	journal  *uploadJournal
+
+	// upload file without pipeline, e.g. encrypted or huge file
+	fallback bool
+}
+
+// UploadBatch uploads multiple files in a pipeline. Transactions to append log entries are sent
+// back to back with explicitly managed nonces, while waiting for receipts and uploading segments
+// run in parallel for at most concurrency files.
+//
+// Returns the upload result of each file in order, and error only if the specified context done.
+// Note, encrypted or huge files that require to split will be uploaded without pipeline, but
+// transactions are still sent with the managed nonces.
+func (uploader *Uploader) UploadBatch(ctx context.Context, filenames []string, concurrency uint, option ...UploadOption) ([]BatchUploadResult, error) {
+	if uploader.flow == nil {
+		return nil, errors.New("Flow contract required for batch upload")
+	}
+
+	var opt UploadOption
+	if len(option) > 0 {
+		opt = option[0]
+	}
+
+	if _, err := opt.partSize(); err != nil {
+		return nil, err
+	}
+
+	if concurrency == 0 {
+		concurrency = 1
+	}
+
+	results := make([]BatchUploadResult, len(filenames))
+	nonces := uploader.flow.NewNonceManager()
+	tasks := make(chan *batchUploadTask, concurrency)
+
+	// all transactions in batch, including the fallback uploads, must be sent with managed nonces
+	opt.nonces = nonces
+
+	var wg sync.WaitGroup
+	for i := uint(0); i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for task := range tasks {
+				uploader.uploadBatchTask(ctx, task, opt, &results[task.index])
+			}
+		}()
+	}
+
+	for i, filename := range filenames {
+		results[i].File = filename
+
+		if err := ctx.Err(); err != nil {
+			results[i].fail(err)
+			continue
+		}
+
+		task, err := uploader.submitBatchTask(ctx, i, filename, opt, nonces, &results[i])
+		if err != nil {
+			logrus.WithError(err).WithField("file", filename).Error("Failed to submit log entry in batch")
+			results[i].fail(err)
+			continue
+		}
+
+		// file already exists
+		if task == nil {
+			continue
+		}
+
+		select {
+		case tasks <- task:
+		case <-ctx.Done():
+			task.close()
+			results[i].fail(ctx.Err())
+		}
+	}
+
+	close(tasks)
+	wg.Wait()
+
+	return results, ctx.Err()
+}
+
+// submitBatchTask prepares the file to upload and sends transaction to append log entry if
+// required. Returns nil if the file already exists on storage node.
+func (uploader *Uploader) submitBatchTask(ctx context.Context, index int, filename string, opt UploadOption,
+	nonces *contract.NonceManager, result *BatchUploadResult) (*batchUploadTask, error) {
+	file, err := Open(filename)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to open file")
+	}
+
+	task := batchUploadTask{
+		index:    index,
+		filename: filename,
+		file:     file,
+	}
+
+	partSize, _ := opt.partSize()
+
+	if opt.Encryption != nil || file.Size() > partSize {
+		task.fallback = true
+		return &task, nil
+	}
+
+	if task.tree, err = file.WithHashWorkers(opt.HashWorkers).WithRootCache(opt.RootCache).MerkleTree(); err != nil {
+		file.Close()
+		return nil, errors.WithMessage(err, "Failed to create file merkle tree")
+	}
+
+	result.Root = task.tree.Root()
+
+	info, err := uploader.clients[0].Ionian().GetFileInfoContext(ctx, result.Root)
+	if err != nil {
+		file.Close()
+		return nil, errors.WithMessage(err, "Failed to get file info from storage node")
+	}
+
+	if info != nil && info.Finalized && !opt.Force {
+		file.Close()
+		result.Status = BatchStatusExists
+		return nil, nil
+	}
+
+	// journal is required to pass the transaction hash to upload stage
+	if task.journal = openJournal(filename+JournalSuffix, result.Root, file.Size()); task.journal == nil {
+		task.journal = newMemoryJournal()
+	}
+
+	// log entry already available on storage node, or transaction sent before process crashed
+	if (info != nil && !info.Finalized) || task.journal.TxHash() != nil {
+		return &task, nil
+	}
+
+	hash, err := uploader.sendLogEntry(ctx, file, opt.Tags, nonces)
+	if err != nil {
+		task.close()
+		return nil, err
+	}
+
+	task.journal.RecordTx(hash)
+
+	return &task, nil
+}
+
+// uploadBatchTask waits for the transaction receipt and then uploads file to storage nodes.
+func (uploader *Uploader) uploadBatchTask(ctx context.Context, task *batchUploadTask, opt UploadOption, result *BatchUploadResult) {
+	defer task.file.Close()
+
+	var err error
+
+	if task.fallback {
+		opt.onSubmitted = func(txHash common.Hash) {
+			result.TxHash = &txHash
+		}
+
+		result.Root, err = uploader.upload(ctx, task.file, task.filename+JournalSuffix, opt)
+	} else {
+		result.TxHash = task.journal.TxHash()
+
+		reporter := newProgressReporter(opt.Progress, task.tree.Root(), task.file.Size())
+
+		if err = uploader.uploadByTree(ctx, task.file, task.tree, opt, reporter, task.journal); err == nil || errors.Is(err, ErrFileAlreadyExists) {
+			task.journal.Remove()
+		} else {
+			task.journal.Close()
+		}
+
+		if err == nil {
+			reporter.report(PhaseCompleted, task.file.NumSegments())
+		}
+	}
+
+	switch {
+	case err == nil:
+		result.Status = BatchStatusUploaded
+	case errors.Is(err, ErrFileAlreadyExists):
+		result.Status = BatchStatusExists
+	default:
+		logrus.WithError(err).WithField("file", task.filename).Error("Failed to upload file in batch")
+		result.fail(err)
+	}
+}
+
+func (task *batchUploadTask) close() {
+	task.journal.Close()
+	task.file.Close()
+}
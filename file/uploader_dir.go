@@ -0,0 +1,107 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// UploadDir uploads all files in the specified folder, and then uploads a manifest that maps
+// relative paths to merkle roots of files. Returns the manifest root, which could be used to
+// download the whole folder.
+//
+// Note, files that already exist on Ionian network will not be uploaded again, and symbolic
+// links or other irregular files will be ignored.
+func (uploader *Uploader) UploadDir(ctx context.Context, dir string, option ...UploadOption) (common.Hash, error) {
+	manifest := NewManifest()
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		// root folder
+		if relPath == "." {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		entry := ManifestEntry{
+			Path: filepath.ToSlash(relPath),
+			Mode: info.Mode(),
+		}
+
+		if !d.IsDir() && !info.Mode().IsRegular() {
+			logrus.WithField("path", path).Warn("Irregular file ignored")
+			return nil
+		}
+
+		if !d.IsDir() && info.Size() > 0 {
+			entry.Size = info.Size()
+
+			if entry.Root, err = uploader.uploadDirFile(ctx, path, option...); err != nil {
+				return errors.WithMessagef(err, "Failed to upload file %v", path)
+			}
+		}
+
+		manifest.Add(entry)
+
+		return nil
+	})
+
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	encoded, err := manifest.Encode()
+	if err != nil {
+		return common.Hash{}, errors.WithMessage(err, "Failed to encode manifest")
+	}
+
+	file, err := OpenReaderAt(bytes.NewReader(encoded), int64(len(encoded)))
+	if err != nil {
+		return common.Hash{}, errors.WithMessage(err, "Failed to open manifest")
+	}
+
+	root, err := uploader.upload(ctx, file, "", option...)
+	if err != nil && !errors.Is(err, ErrFileAlreadyExists) {
+		return common.Hash{}, errors.WithMessage(err, "Failed to upload manifest")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"entries": len(manifest.Entries),
+		"root":    root,
+	}).Info("Succeeded to upload folder")
+
+	return root, nil
+}
+
+func (uploader *Uploader) uploadDirFile(ctx context.Context, filename string, option ...UploadOption) (common.Hash, error) {
+	file, err := Open(filename)
+	if err != nil {
+		return common.Hash{}, errors.WithMessage(err, "Failed to open file")
+	}
+	defer file.Close()
+
+	root, err := uploader.upload(ctx, file, "", option...)
+	if errors.Is(err, ErrFileAlreadyExists) {
+		logrus.WithField("root", root).Info("File already exists on Ionian network")
+		return root, nil
+	}
+
+	return root, err
+}
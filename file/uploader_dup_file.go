@@ -1,9 +1,9 @@
 package file
 
 import (
+	"context"
 	"time"
 
-	"github.com/Ionian-Web3-Storage/ionian-client/contract"
 	"github.com/Ionian-Web3-Storage/ionian-client/node"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/pkg/errors"
@@ -15,30 +15,16 @@ var submissionEventHash = common.HexToHash("0x398e4f14f8588468d3654c03dc3f266e5a
 // uploadDuplicatedFile uploads file to storage node that already exists by root.
 // In this case, user only need to submit transaction on blockchain, and wait for
 // file finality on storage node.
-func (uploader *Uploader) uploadDuplicatedFile(file *File, tags []byte, root common.Hash) error {
+func (uploader *Uploader) uploadDuplicatedFile(ctx context.Context, file *File, opt UploadOption, root common.Hash, reporter *progressReporter, journal *uploadJournal) error {
 	// submit transaction on blockchain
-	receipt, err := uploader.submitLogEntry(file, tags)
+	txSeq, err := uploader.submitLogEntry(ctx, file, opt, reporter, journal)
 	if err != nil {
 		return errors.WithMessage(err, "Failed to submit log entry")
 	}
 
-	// parse submission from event log
-	var submission *contract.FlowSubmission
-	for _, v := range receipt.Logs {
-		if v.Topics[0] == submissionEventHash {
-			log := contract.ConvertToGethLog(v)
-
-			if submission, err = uploader.flow.ParseSubmission(*log); err != nil {
-				return err
-			}
-
-			break
-		}
-	}
-
 	// wait for finality from storage node
-	txSeq := submission.SubmissionIndex.Uint64()
-	info, err := uploader.waitForFileFinalityByTxSeq(txSeq)
+	reporter.report(PhaseFinalizing, file.NumSegments())
+	info, err := uploader.waitForFileFinalityByTxSeq(ctx, txSeq)
 	if err != nil {
 		return errors.WithMessagef(err, "Failed to wait for finality for tx %v", txSeq)
 	}
@@ -50,19 +36,30 @@ func (uploader *Uploader) uploadDuplicatedFile(file *File, tags []byte, root com
 	return nil
 }
 
-func (uploader *Uploader) waitForFileFinalityByTxSeq(txSeq uint64) (*node.FileInfo, error) {
+func (uploader *Uploader) waitForFileFinalityByTxSeq(ctx context.Context, txSeq uint64) (info *node.FileInfo, err error) {
 	logrus.WithField("txSeq", txSeq).Info("Wait for finality on storage node")
 
-	for {
-		time.Sleep(time.Second)
+	for _, client := range uploader.clients {
+		for {
+			if err = sleep(ctx, time.Second); err != nil {
+				return nil, err
+			}
 
-		info, err := uploader.client.GetFileInfoByTxSeq(txSeq)
-		if err != nil {
-			return nil, errors.WithMessage(err, "Failed to get file info from storage node")
-		}
+			info, err = client.Ionian().GetFileInfoByTxSeqContext(ctx, txSeq)
+			if node.IsRetryableError(err) {
+				logrus.WithError(err).WithField("node", client.URL()).Warn("Failed to get file info from storage node, retry later")
+				continue
+			}
+
+			if err != nil {
+				return nil, errors.WithMessagef(err, "Failed to get file info from storage node %v", client.URL())
+			}
 
-		if info != nil && info.Finalized {
-			return info, nil
+			if info != nil && info.Finalized {
+				break
+			}
 		}
 	}
+
+	return info, nil
 }
@@ -0,0 +1,156 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// uploadParts splits the large file into parts of the specified size, and uploads each part with
+// a separate submission. Then, uploads a parts manifest that ties the part roots together, and
+// returns the manifest root, which could be used to download the whole file.
+func (uploader *Uploader) uploadParts(ctx context.Context, file *File, partSize int64, journalPath string, opt UploadOption) (common.Hash, error) {
+	numParts := numSplits(file.Size(), int(partSize))
+
+	// only reports the transaction of parts manifest
+	partOpt := opt
+	partOpt.onSubmitted = nil
+
+	// parts manifest is not allowed to split again, which could not be reassembled once downloaded
+	if err := checkPartsManifestSize(file.Size(), partSize); err != nil {
+		return common.Hash{}, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"size":  file.Size(),
+		"parts": numParts,
+	}).Info("Split large file into parts to upload")
+
+	manifest := NewPartsManifest()
+
+	for i := uint64(0); i < numParts; i++ {
+		offset := int64(i) * partSize
+		size := file.Size() - offset
+		if size > partSize {
+			size = partSize
+		}
+
+		if opt.Progress != nil {
+			partOpt.Progress = &partsProgress{opt.Progress, file.Size(), offset, false}
+		}
+
+		part, err := file.Part(offset, size)
+		if err != nil {
+			return common.Hash{}, errors.WithMessagef(err, "Failed to open part %v", i)
+		}
+
+		var partJournalPath string
+		if len(journalPath) > 0 {
+			partJournalPath = fmt.Sprintf("%v.%v", journalPath, i)
+		}
+
+		root, err := uploader.upload(ctx, part, partJournalPath, partOpt)
+		if errors.Is(err, ErrFileAlreadyExists) {
+			logrus.WithField("root", root).Info("Part already exists on Ionian network")
+		} else if err != nil {
+			return common.Hash{}, errors.WithMessagef(err, "Failed to upload part %v", i)
+		}
+
+		manifest.Add(ManifestEntry{
+			Path: fmt.Sprintf("part-%v", i),
+			Root: root,
+			Size: size,
+		})
+	}
+
+	encoded, err := manifest.Encode()
+	if err != nil {
+		return common.Hash{}, errors.WithMessage(err, "Failed to encode parts manifest")
+	}
+
+	manifestFile, err := OpenReaderAt(bytes.NewReader(encoded), int64(len(encoded)))
+	if err != nil {
+		return common.Hash{}, errors.WithMessage(err, "Failed to open parts manifest")
+	}
+
+	if opt.Progress != nil {
+		opt.Progress = &partsProgress{opt.Progress, file.Size(), file.Size(), true}
+	}
+
+	root, err := uploader.upload(ctx, manifestFile, "", opt)
+	if err != nil && !errors.Is(err, ErrFileAlreadyExists) {
+		return common.Hash{}, errors.WithMessage(err, "Failed to upload parts manifest")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"parts": numParts,
+		"root":  root,
+	}).Info("Succeeded to upload file in parts")
+
+	return root, nil
+}
+
+// checkPartsManifestSize checks the size of parts manifest before uploading any part, which should
+// neither exceed the part size nor the max size of parts manifest to download. Note, merkle roots
+// are encoded in fixed length, so the manifest size is known without uploading parts.
+func checkPartsManifestSize(fileSize, partSize int64) error {
+	manifest := NewPartsManifest()
+
+	for offset := int64(0); offset < fileSize; offset += partSize {
+		size := fileSize - offset
+		if size > partSize {
+			size = partSize
+		}
+
+		manifest.Add(ManifestEntry{
+			Path: fmt.Sprintf("part-%v", len(manifest.Entries)),
+			Size: size,
+		})
+	}
+
+	encoded, err := manifest.Encode()
+	if err != nil {
+		return errors.WithMessage(err, "Failed to encode parts manifest")
+	}
+
+	if size := int64(len(encoded)); size > partSize || size > maxPartsManifestSize {
+		return errors.Errorf("Too many parts %v to split, parts manifest size %v exceeds part size or %v, please increase part size",
+			len(manifest.Entries), size, maxPartsManifestSize)
+	}
+
+	return nil
+}
+
+// partsProgress reports the progress of a part cumulatively over the whole file, so that progress
+// never goes backwards across parts.
+type partsProgress struct {
+	listener ProgressListener
+	size     int64 // size of the whole file
+	offset   int64 // offset of part in file, which is aligned with segments
+	manifest bool  // parts manifest is uploaded at last
+}
+
+// OnProgress implements the ProgressListener interface.
+func (progress *partsProgress) OnProgress(p Progress) {
+	// only completed once parts manifest uploaded
+	if p.Phase == PhaseCompleted && !progress.manifest {
+		p.Phase = PhaseUploading
+	}
+
+	p.TotalSegments = numSplits(progress.size, DefaultSegmentSize)
+	p.TotalBytes = progress.size
+
+	if p.Segments += uint64(progress.offset / DefaultSegmentSize); p.Segments > p.TotalSegments {
+		p.Segments = p.TotalSegments
+	}
+
+	if p.Bytes += progress.offset; p.Bytes > p.TotalBytes {
+		p.Bytes = p.TotalBytes
+	}
+
+	progress.listener.OnProgress(p)
+}
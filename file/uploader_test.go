@@ -0,0 +1,43 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadMultipleNodes(t *testing.T) {
+	size := DefaultSegmentSize*5 + 100
+	data := createTestData(size)
+
+	var mocks []*mockNode
+	var clients []*node.Client
+
+	for i := 0; i < 3; i++ {
+		mock, client := newMockUploadNode(t, data)
+		mocks = append(mocks, mock)
+		clients = append(clients, client)
+	}
+
+	// temporary failures on a storage node
+	mocks[1].failUploads = 2
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err := NewUploaderLight(clients...).UploadReaderAt(ctx, bytes.NewReader(data), int64(size), UploadOption{
+		SegmentsPerNode: 2,
+		Retry:           RetryOption{Interval: 10 * time.Millisecond},
+	})
+	assert.NoError(t, err)
+
+	// every storage node holds the whole file
+	for _, mock := range mocks {
+		assert.Equal(t, 6, mock.numUploaded())
+		assert.True(t, mock.fileInfo().Finalized)
+	}
+}
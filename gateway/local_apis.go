@@ -10,6 +10,12 @@ import (
 
 var LocalFileRepo string = "."
 
+// LocalRootCache caches segment roots of local files, nil means no cache.
+var LocalRootCache *file.RootCache
+
+// progresses tracks the latest progress of file upload or download.
+var progresses = newProgressTracker(defaultProgressRetention)
+
 func listNodes(c *gin.Context) (interface{}, error) {
 	var nodes []string
 
@@ -49,14 +55,14 @@ func getLocalFileInfo(c *gin.Context) (interface{}, error) {
 	}
 	defer file.Close()
 
-	tree, err := file.MerkleTree()
+	root, err := file.WithRootCache(LocalRootCache).MerkleRoot()
 	if err != nil {
 		return nil, err
 	}
 
 	return map[string]interface{}{
 		"name":     file.Name(),
-		"root":     tree.Root(),
+		"root":     root,
 		"size":     file.Size(),
 		"segments": file.NumSegments(),
 	}, nil
@@ -76,7 +82,7 @@ func getFileStatus(c *gin.Context) (interface{}, error) {
 	var notFinalized bool
 
 	for _, client := range allClients {
-		info, err := client.Ionian().GetFileInfo(root)
+		info, err := client.Ionian().GetFileInfoContext(c.Request.Context(), root)
 		if err != nil {
 			return nil, err
 		}
@@ -115,8 +121,16 @@ func uploadLocalFile(c *gin.Context) (interface{}, error) {
 	uploader := file.NewUploaderLight(allClients[input.Node])
 
 	filename := getFilePath(input.Path, false)
+	listener, done := progresses.track(filename)
+	opt := file.UploadOption{
+		Progress:  listener,
+		RootCache: LocalRootCache,
+	}
 
-	if err := uploader.Upload(filename); err != nil {
+	err := uploader.UploadContext(c.Request.Context(), filename, opt)
+	done(err)
+
+	if err != nil {
 		return nil, err
 	}
 
@@ -138,13 +152,36 @@ func downloadFileLocal(c *gin.Context) (interface{}, error) {
 		return nil, ErrValidation.WithData("node index out of bound")
 	}
 
-	downloader := file.NewDownloader(allClients[input.Node])
-
 	filename := getFilePath(input.Path, true)
 
-	if err := downloader.Download(input.Root, filename, false); err != nil {
+	listener, done := progresses.track(filename)
+	downloader := file.NewDownloader(allClients[input.Node]).WithProgress(listener)
+
+	err := downloader.DownloadContext(c.Request.Context(), input.Root, filename, false)
+	done(err)
+
+	if err != nil {
 		return nil, err
 	}
 
 	return nil, nil
 }
+
+// getProgress returns the latest progress of file upload or download.
+func getProgress(c *gin.Context) (interface{}, error) {
+	var input struct {
+		Path     string `form:"path" json:"path" binding:"required"`
+		Download bool   `form:"download" json:"download"`
+	}
+
+	if err := c.ShouldBind(&input); err != nil {
+		return nil, err
+	}
+
+	progress, ok := progresses.get(getFilePath(input.Path, input.Download))
+	if !ok {
+		return nil, ErrValidation.WithData("progress not found")
+	}
+
+	return progress, nil
+}
@@ -0,0 +1,88 @@
+package gateway
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/file"
+)
+
+// defaultProgressRetention is the duration to keep progress after file upload or download terminated,
+// so that clients could still query the final progress.
+const defaultProgressRetention = 10 * time.Minute
+
+// progressEntry is the latest progress of file upload or download.
+type progressEntry struct {
+	file.Progress
+	Error string `json:"error,omitempty"` // error message if terminated with error
+}
+
+// progressTracker tracks the latest progress of file upload or download, keyed by local file path.
+// Progress is removed after retention once upload or download terminated.
+type progressTracker struct {
+	retention time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*progressEntry
+}
+
+func newProgressTracker(retention time.Duration) *progressTracker {
+	return &progressTracker{
+		retention: retention,
+		entries:   make(map[string]*progressEntry),
+	}
+}
+
+// track starts to track progress of the specified file, and returns a listener to receive progress
+// events along with a function that should be called once upload or download terminated.
+func (tracker *progressTracker) track(filename string) (file.ProgressListener, func(err error)) {
+	entry := &progressEntry{}
+
+	tracker.mu.Lock()
+	tracker.entries[filename] = entry
+	tracker.mu.Unlock()
+
+	listener := file.ProgressFunc(func(progress file.Progress) {
+		tracker.mu.Lock()
+		defer tracker.mu.Unlock()
+
+		entry.Progress = progress
+	})
+
+	done := func(err error) {
+		tracker.mu.Lock()
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		tracker.mu.Unlock()
+
+		time.AfterFunc(tracker.retention, func() {
+			tracker.remove(filename, entry)
+		})
+	}
+
+	return listener, done
+}
+
+// remove removes the progress entry of specified file, unless tracked again for a new upload or download.
+func (tracker *progressTracker) remove(filename string, entry *progressEntry) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	if tracker.entries[filename] == entry {
+		delete(tracker.entries, filename)
+	}
+}
+
+// get returns a copy of the latest progress of specified file.
+func (tracker *progressTracker) get(filename string) (progressEntry, bool) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	entry, ok := tracker.entries[filename]
+	if !ok {
+		return progressEntry{}, false
+	}
+
+	return *entry, true
+}
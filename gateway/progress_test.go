@@ -0,0 +1,54 @@
+package gateway
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ionian-Web3-Storage/ionian-client/file"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressTracker(t *testing.T) {
+	tracker := newProgressTracker(50 * time.Millisecond)
+
+	_, ok := tracker.get("a")
+	assert.False(t, ok)
+
+	listener, done := tracker.track("a")
+	listener.OnProgress(file.Progress{Phase: file.PhaseUploading, Segments: 3})
+
+	entry, ok := tracker.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, file.PhaseUploading, entry.Phase)
+	assert.Equal(t, uint64(3), entry.Segments)
+
+	// retained for a while once terminated
+	done(errors.New("boom"))
+
+	entry, ok = tracker.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "boom", entry.Error)
+
+	time.Sleep(100 * time.Millisecond)
+
+	_, ok = tracker.get("a")
+	assert.False(t, ok)
+}
+
+func TestProgressTrackerRestart(t *testing.T) {
+	tracker := newProgressTracker(50 * time.Millisecond)
+
+	_, done := tracker.track("a")
+	done(nil)
+
+	// tracked again before the previous one removed
+	listener, _ := tracker.track("a")
+	listener.OnProgress(file.Progress{Phase: file.PhaseDownloading})
+
+	time.Sleep(100 * time.Millisecond)
+
+	entry, ok := tracker.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, file.PhaseDownloading, entry.Phase)
+}
@@ -45,6 +45,7 @@ func newLocalRouter() *gin.Engine {
 	localApi.GET("/status", wrap(getFileStatus))
 	localApi.POST("/upload", wrap(uploadLocalFile))
 	localApi.POST("/download", wrap(downloadFileLocal))
+	localApi.GET("/progress", wrap(getProgress))
 
 	return router
 }
@@ -1,6 +1,7 @@
 package kv
 
 import (
+	"context"
 	"errors"
 
 	"github.com/Ionian-Web3-Storage/ionian-client/node"
@@ -24,12 +25,12 @@ func (iter *Iterator) KeyValue() *node.KeyValue {
 	return iter.currentPair
 }
 
-func (iter *Iterator) move(kv *node.KeyValue) error {
+func (iter *Iterator) move(ctx context.Context, kv *node.KeyValue) error {
 	if kv == nil {
 		iter.currentPair = nil
 		return nil
 	}
-	value, err := iter.client.GetValue(iter.streamId, kv.Key, iter.version)
+	value, err := iter.client.GetValueContext(ctx, iter.streamId, kv.Key, iter.version)
 	if err != nil {
 		return err
 	}
@@ -43,55 +44,79 @@ func (iter *Iterator) move(kv *node.KeyValue) error {
 }
 
 func (iter *Iterator) SeekBefore(key []byte) error {
-	kv, err := iter.client.GetPrev(iter.streamId, key, 0, 0, true, iter.version)
+	return iter.SeekBeforeContext(context.Background(), key)
+}
+
+func (iter *Iterator) SeekBeforeContext(ctx context.Context, key []byte) error {
+	kv, err := iter.client.GetPrevContext(ctx, iter.streamId, key, 0, 0, true, iter.version)
 	if err != nil {
 		return err
 	}
-	return iter.move(kv)
+	return iter.move(ctx, kv)
 }
 
 func (iter *Iterator) SeekAfter(key []byte) error {
-	kv, err := iter.client.GetNext(iter.streamId, key, 0, 0, true, iter.version)
+	return iter.SeekAfterContext(context.Background(), key)
+}
+
+func (iter *Iterator) SeekAfterContext(ctx context.Context, key []byte) error {
+	kv, err := iter.client.GetNextContext(ctx, iter.streamId, key, 0, 0, true, iter.version)
 	if err != nil {
 		return err
 	}
-	return iter.move(kv)
+	return iter.move(ctx, kv)
 }
 
 func (iter *Iterator) SeekToFirst() error {
-	kv, err := iter.client.GetFirst(iter.streamId, 0, 0, iter.version)
+	return iter.SeekToFirstContext(context.Background())
+}
+
+func (iter *Iterator) SeekToFirstContext(ctx context.Context) error {
+	kv, err := iter.client.GetFirstContext(ctx, iter.streamId, 0, 0, iter.version)
 	if err != nil {
 		return err
 	}
-	return iter.move(kv)
+	return iter.move(ctx, kv)
 }
 
 func (iter *Iterator) SeekToLast() error {
-	kv, err := iter.client.GetLast(iter.streamId, 0, 0, iter.version)
+	return iter.SeekToLastContext(context.Background())
+}
+
+func (iter *Iterator) SeekToLastContext(ctx context.Context) error {
+	kv, err := iter.client.GetLastContext(ctx, iter.streamId, 0, 0, iter.version)
 	if err != nil {
 		return err
 	}
-	return iter.move(kv)
+	return iter.move(ctx, kv)
 }
 
 func (iter *Iterator) Next() error {
+	return iter.NextContext(context.Background())
+}
+
+func (iter *Iterator) NextContext(ctx context.Context) error {
 	if !iter.Valid() {
 		return errIteratorInvalid
 	}
-	kv, err := iter.client.GetNext(iter.streamId, iter.currentPair.Key, 0, 0, false, iter.version)
+	kv, err := iter.client.GetNextContext(ctx, iter.streamId, iter.currentPair.Key, 0, 0, false, iter.version)
 	if err != nil {
 		return err
 	}
-	return iter.move(kv)
+	return iter.move(ctx, kv)
 }
 
 func (iter *Iterator) Prev() error {
+	return iter.PrevContext(context.Background())
+}
+
+func (iter *Iterator) PrevContext(ctx context.Context) error {
 	if !iter.Valid() {
 		return errIteratorInvalid
 	}
-	kv, err := iter.client.GetPrev(iter.streamId, iter.currentPair.Key, 0, 0, false, iter.version)
+	kv, err := iter.client.GetPrevContext(ctx, iter.streamId, iter.currentPair.Key, 0, 0, false, iter.version)
 	if err != nil {
 		return err
 	}
-	return iter.move(kv)
+	return iter.move(ctx, kv)
 }
@@ -1,8 +1,9 @@
 package kv
 
 import (
+	"bytes"
+	"context"
 	"math"
-	"os"
 
 	"github.com/Ionian-Web3-Storage/ionian-client/contract"
 	"github.com/Ionian-Web3-Storage/ionian-client/file"
@@ -43,6 +44,11 @@ func (c *Client) NewIterator(streamId common.Hash, version ...uint64) *Iterator
 }
 
 func (c *Client) GetValue(streamId common.Hash, key []byte, version ...uint64) (val *node.Value, err error) {
+	return c.GetValueContext(context.Background(), streamId, key, version...)
+}
+
+// GetValueContext returns the whole value for the specified stream key, which may be queried in pages.
+func (c *Client) GetValueContext(ctx context.Context, streamId common.Hash, key []byte, version ...uint64) (val *node.Value, err error) {
 	var v uint64
 	v = math.MaxUint64
 	if len(version) > 0 {
@@ -55,7 +61,7 @@ func (c *Client) GetValue(streamId common.Hash, key []byte, version ...uint64) (
 	}
 	for {
 		var seg *node.Value
-		seg, err = c.node.KV().GetValue(streamId, key, uint64(len(val.Data)), maxQuerySize, val.Version)
+		seg, err = c.node.KV().GetValueContext(ctx, streamId, key, uint64(len(val.Data)), maxQuerySize, val.Version)
 		if err != nil {
 			return
 		}
@@ -75,51 +81,99 @@ func (c *Client) GetValue(streamId common.Hash, key []byte, version ...uint64) (
 
 // Get returns paginated value for the specified stream key and offset.
 func (c *Client) Get(streamId common.Hash, key []byte, startIndex, length uint64, version ...uint64) (val *node.Value, err error) {
-	return c.node.KV().GetValue(streamId, key, startIndex, length, version...)
+	return c.GetContext(context.Background(), streamId, key, startIndex, length, version...)
+}
+
+func (c *Client) GetContext(ctx context.Context, streamId common.Hash, key []byte, startIndex, length uint64, version ...uint64) (val *node.Value, err error) {
+	return c.node.KV().GetValueContext(ctx, streamId, key, startIndex, length, version...)
 }
 
 func (c *Client) GetNext(streamId common.Hash, key []byte, startIndex, length uint64, inclusive bool, version ...uint64) (val *node.KeyValue, err error) {
-	return c.node.KV().GetNext(streamId, key, startIndex, length, inclusive, version...)
+	return c.GetNextContext(context.Background(), streamId, key, startIndex, length, inclusive, version...)
+}
+
+func (c *Client) GetNextContext(ctx context.Context, streamId common.Hash, key []byte, startIndex, length uint64, inclusive bool, version ...uint64) (val *node.KeyValue, err error) {
+	return c.node.KV().GetNextContext(ctx, streamId, key, startIndex, length, inclusive, version...)
 }
 
 func (c *Client) GetPrev(streamId common.Hash, key []byte, startIndex, length uint64, inclusive bool, version ...uint64) (val *node.KeyValue, err error) {
-	return c.node.KV().GetPrev(streamId, key, startIndex, length, inclusive, version...)
+	return c.GetPrevContext(context.Background(), streamId, key, startIndex, length, inclusive, version...)
+}
+
+func (c *Client) GetPrevContext(ctx context.Context, streamId common.Hash, key []byte, startIndex, length uint64, inclusive bool, version ...uint64) (val *node.KeyValue, err error) {
+	return c.node.KV().GetPrevContext(ctx, streamId, key, startIndex, length, inclusive, version...)
 }
 
 func (c *Client) GetFirst(streamId common.Hash, startIndex, length uint64, version ...uint64) (val *node.KeyValue, err error) {
-	return c.node.KV().GetFirst(streamId, startIndex, length, version...)
+	return c.GetFirstContext(context.Background(), streamId, startIndex, length, version...)
+}
+
+func (c *Client) GetFirstContext(ctx context.Context, streamId common.Hash, startIndex, length uint64, version ...uint64) (val *node.KeyValue, err error) {
+	return c.node.KV().GetFirstContext(ctx, streamId, startIndex, length, version...)
 }
 
 func (c *Client) GetLast(streamId common.Hash, startIndex, length uint64, version ...uint64) (val *node.KeyValue, err error) {
-	return c.node.KV().GetLast(streamId, startIndex, length, version...)
+	return c.GetLastContext(context.Background(), streamId, startIndex, length, version...)
+}
+
+func (c *Client) GetLastContext(ctx context.Context, streamId common.Hash, startIndex, length uint64, version ...uint64) (val *node.KeyValue, err error) {
+	return c.node.KV().GetLastContext(ctx, streamId, startIndex, length, version...)
 }
 
 func (c *Client) GetTransactionResult(txSeq uint64) (result string, err error) {
-	return c.node.KV().GetTransactionResult(txSeq)
+	return c.GetTransactionResultContext(context.Background(), txSeq)
+}
+
+func (c *Client) GetTransactionResultContext(ctx context.Context, txSeq uint64) (result string, err error) {
+	return c.node.KV().GetTransactionResultContext(ctx, txSeq)
 }
 
 func (c *Client) GetHoldingStreamIds() (streamIds []common.Hash, err error) {
-	return c.node.KV().GetHoldingStreamIds()
+	return c.GetHoldingStreamIdsContext(context.Background())
+}
+
+func (c *Client) GetHoldingStreamIdsContext(ctx context.Context) (streamIds []common.Hash, err error) {
+	return c.node.KV().GetHoldingStreamIdsContext(ctx)
 }
 
 func (c *Client) HasWritePermission(account common.Address, streamId common.Hash, key []byte, version ...uint64) (hasPermission bool, err error) {
-	return c.node.KV().HasWritePermission(account, streamId, key, version...)
+	return c.HasWritePermissionContext(context.Background(), account, streamId, key, version...)
+}
+
+func (c *Client) HasWritePermissionContext(ctx context.Context, account common.Address, streamId common.Hash, key []byte, version ...uint64) (hasPermission bool, err error) {
+	return c.node.KV().HasWritePermissionContext(ctx, account, streamId, key, version...)
 }
 
 func (c *Client) IsAdmin(account common.Address, streamId common.Hash, version ...uint64) (isAdmin bool, err error) {
-	return c.node.KV().IsAdmin(account, streamId, version...)
+	return c.IsAdminContext(context.Background(), account, streamId, version...)
+}
+
+func (c *Client) IsAdminContext(ctx context.Context, account common.Address, streamId common.Hash, version ...uint64) (isAdmin bool, err error) {
+	return c.node.KV().IsAdminContext(ctx, account, streamId, version...)
 }
 
 func (c *Client) IsSpecialKey(streamId common.Hash, key []byte, version ...uint64) (isSpecialKey bool, err error) {
-	return c.node.KV().IsSpecialKey(streamId, key, version...)
+	return c.IsSpecialKeyContext(context.Background(), streamId, key, version...)
+}
+
+func (c *Client) IsSpecialKeyContext(ctx context.Context, streamId common.Hash, key []byte, version ...uint64) (isSpecialKey bool, err error) {
+	return c.node.KV().IsSpecialKeyContext(ctx, streamId, key, version...)
 }
 
 func (c *Client) IsWriterOfKey(account common.Address, streamId common.Hash, key []byte, version ...uint64) (isWriter bool, err error) {
-	return c.node.KV().IsWriterOfKey(account, streamId, key, version...)
+	return c.IsWriterOfKeyContext(context.Background(), account, streamId, key, version...)
+}
+
+func (c *Client) IsWriterOfKeyContext(ctx context.Context, account common.Address, streamId common.Hash, key []byte, version ...uint64) (isWriter bool, err error) {
+	return c.node.KV().IsWriterOfKeyContext(ctx, account, streamId, key, version...)
 }
 
 func (c *Client) IsWriterOfStream(account common.Address, streamId common.Hash, version ...uint64) (isWriter bool, err error) {
-	return c.node.KV().IsWriterOfStream(account, streamId, version...)
+	return c.IsWriterOfStreamContext(context.Background(), account, streamId, version...)
+}
+
+func (c *Client) IsWriterOfStreamContext(ctx context.Context, account common.Address, streamId common.Hash, version ...uint64) (isWriter bool, err error) {
+	return c.node.KV().IsWriterOfStreamContext(ctx, account, streamId, version...)
 }
 
 // Batcher returns a Batcher instance for kv operations in batch.
@@ -144,50 +198,32 @@ func newBatcher(version uint64, client *Client) *Batcher {
 // Note, this is a time consuming operation, e.g. several seconds or even longer.
 // When it comes to a time sentitive context, it should be executed in a separate go-routine.
 func (b *Batcher) Exec() error {
+	return b.ExecContext(context.Background())
+}
+
+// ExecContext submit the kv operations to Ionian network in batch, and terminates once the
+// specified context is done.
+func (b *Batcher) ExecContext(ctx context.Context) error {
 	// build stream data
 	data, err := b.Build()
 	if err != nil {
 		return errors.WithMessage(err, "Failed to build stream data")
 	}
 
-	// prepare tmp file to upload
-	tmpFilename, err := b.writeTempFile(data)
+	encoded, err := data.Encode()
 	if err != nil {
-		return errors.WithMessage(err, "Failed to write stream data to temp file")
+		return errors.WithMessage(err, "Failed to encode data")
 	}
 
-	// upload file
+	// upload encoded stream data
 	uploader := file.NewUploader(b.client.flow, b.client.node)
 	opt := file.UploadOption{
 		Tags:  b.BuildTags(),
 		Force: true,
 	}
-	if err = uploader.Upload(tmpFilename, opt); err != nil {
-		return errors.WithMessagef(err, "Failed to upload file %v", tmpFilename)
-	}
-
-	// delete tmp file if completed
-	return os.Remove(tmpFilename)
-}
-
-// writeTempFile encodes the specified stream data and write to a temp file.
-//
-// Note, the temp file should be removed via the returned temp file name.
-func (b *Batcher) writeTempFile(data *StreamData) (string, error) {
-	file, err := os.CreateTemp("", "ionian-kv-*")
-	if err != nil {
-		return "", errors.WithMessage(err, "Failed to create temp file")
-	}
-	defer file.Close()
-
-	encoded, err := data.Encode()
-	if err != nil {
-		return "", errors.WithMessage(err, "Failed to encode data")
-	}
-
-	if _, err = file.Write(encoded); err != nil {
-		return "", errors.WithMessagef(err, "Failed to write data to %v", file.Name())
+	if err = uploader.UploadReaderAt(ctx, bytes.NewReader(encoded), int64(len(encoded)), opt); err != nil {
+		return errors.WithMessage(err, "Failed to upload stream data")
 	}
 
-	return file.Name(), nil
+	return nil
 }
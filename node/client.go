@@ -80,32 +80,56 @@ type IonianClient struct {
 }
 
 func (c *IonianClient) GetStatus() (status Status, err error) {
-	err = c.provider.CallContext(context.Background(), &status, "ionian_getStatus")
+	return c.GetStatusContext(context.Background())
+}
+
+func (c *IonianClient) GetStatusContext(ctx context.Context) (status Status, err error) {
+	err = c.provider.CallContext(ctx, &status, "ionian_getStatus")
 	return
 }
 
 func (c *IonianClient) GetFileInfo(root common.Hash) (file *FileInfo, err error) {
-	err = c.provider.CallContext(context.Background(), &file, "ionian_getFileInfo", root)
+	return c.GetFileInfoContext(context.Background(), root)
+}
+
+func (c *IonianClient) GetFileInfoContext(ctx context.Context, root common.Hash) (file *FileInfo, err error) {
+	err = c.provider.CallContext(ctx, &file, "ionian_getFileInfo", root)
 	return
 }
 
 func (c *IonianClient) GetFileInfoByTxSeq(txSeq uint64) (file *FileInfo, err error) {
-	err = c.provider.CallContext(context.Background(), &file, "ionian_getFileInfoByTxSeq", txSeq)
+	return c.GetFileInfoByTxSeqContext(context.Background(), txSeq)
+}
+
+func (c *IonianClient) GetFileInfoByTxSeqContext(ctx context.Context, txSeq uint64) (file *FileInfo, err error) {
+	err = c.provider.CallContext(ctx, &file, "ionian_getFileInfoByTxSeq", txSeq)
 	return
 }
 
 func (c *IonianClient) UploadSegment(segment SegmentWithProof) (ret int, err error) {
-	err = c.provider.CallContext(context.Background(), &ret, "ionian_uploadSegment", segment)
+	return c.UploadSegmentContext(context.Background(), segment)
+}
+
+func (c *IonianClient) UploadSegmentContext(ctx context.Context, segment SegmentWithProof) (ret int, err error) {
+	err = c.provider.CallContext(ctx, &ret, "ionian_uploadSegment", segment)
 	return
 }
 
 func (c *IonianClient) DownloadSegment(root common.Hash, startIndex, endIndex uint64) (data []byte, err error) {
-	err = c.provider.CallContext(context.Background(), &data, "ionian_downloadSegment", root, startIndex, endIndex)
+	return c.DownloadSegmentContext(context.Background(), root, startIndex, endIndex)
+}
+
+func (c *IonianClient) DownloadSegmentContext(ctx context.Context, root common.Hash, startIndex, endIndex uint64) (data []byte, err error) {
+	err = c.provider.CallContext(ctx, &data, "ionian_downloadSegment", root, startIndex, endIndex)
 	return
 }
 
 func (c *IonianClient) DownloadSegmentWithProof(root common.Hash, index uint64) (segment *SegmentWithProof, err error) {
-	err = c.provider.CallContext(context.Background(), &segment, "ionian_downloadSegmentWithProof", root, index)
+	return c.DownloadSegmentWithProofContext(context.Background(), root, index)
+}
+
+func (c *IonianClient) DownloadSegmentWithProofContext(ctx context.Context, root common.Hash, index uint64) (segment *SegmentWithProof, err error) {
+	err = c.provider.CallContext(ctx, &segment, "ionian_downloadSegmentWithProof", root, index)
 	return
 }
 
@@ -115,16 +139,28 @@ type AdminClient struct {
 }
 
 func (c *AdminClient) Shutdown() (ret int, err error) {
-	err = c.provider.CallContext(context.Background(), &ret, "admin_shutdown")
+	return c.ShutdownContext(context.Background())
+}
+
+func (c *AdminClient) ShutdownContext(ctx context.Context) (ret int, err error) {
+	err = c.provider.CallContext(ctx, &ret, "admin_shutdown")
 	return
 }
 
 func (c *AdminClient) StartSyncFile(txSeq uint64) (ret int, err error) {
-	err = c.provider.CallContext(context.Background(), &ret, "admin_startSyncFile", txSeq)
+	return c.StartSyncFileContext(context.Background(), txSeq)
+}
+
+func (c *AdminClient) StartSyncFileContext(ctx context.Context, txSeq uint64) (ret int, err error) {
+	err = c.provider.CallContext(ctx, &ret, "admin_startSyncFile", txSeq)
 	return
 }
 
 func (c *AdminClient) GetSyncStatus(txSeq uint64) (status string, err error) {
-	err = c.provider.CallContext(context.Background(), &status, "admin_getSyncStatus", txSeq)
+	return c.GetSyncStatusContext(context.Background(), txSeq)
+}
+
+func (c *AdminClient) GetSyncStatusContext(ctx context.Context, txSeq uint64) (status string, err error) {
+	err = c.provider.CallContext(ctx, &status, "admin_getSyncStatus", txSeq)
 	return
 }
@@ -12,101 +12,149 @@ type KvClient struct {
 }
 
 func (c *KvClient) GetValue(streamId common.Hash, key []byte, startIndex, length uint64, version ...uint64) (val *Value, err error) {
+	return c.GetValueContext(context.Background(), streamId, key, startIndex, length, version...)
+}
+
+func (c *KvClient) GetValueContext(ctx context.Context, streamId common.Hash, key []byte, startIndex, length uint64, version ...uint64) (val *Value, err error) {
 	args := []interface{}{streamId, key, startIndex, length}
 	if len(version) > 0 {
 		args = append(args, version[0])
 	}
-	err = c.provider.CallContext(context.Background(), &val, "kv_getValue", args...)
+	err = c.provider.CallContext(ctx, &val, "kv_getValue", args...)
 	return
 }
 
 func (c *KvClient) GetNext(streamId common.Hash, key []byte, startIndex, length uint64, inclusive bool, version ...uint64) (val *KeyValue, err error) {
+	return c.GetNextContext(context.Background(), streamId, key, startIndex, length, inclusive, version...)
+}
+
+func (c *KvClient) GetNextContext(ctx context.Context, streamId common.Hash, key []byte, startIndex, length uint64, inclusive bool, version ...uint64) (val *KeyValue, err error) {
 	args := []interface{}{streamId, key, startIndex, length, inclusive}
 	if len(version) > 0 {
 		args = append(args, version[0])
 	}
-	err = c.provider.CallContext(context.Background(), &val, "kv_getNext", args...)
+	err = c.provider.CallContext(ctx, &val, "kv_getNext", args...)
 	return
 }
 
 func (c *KvClient) GetPrev(streamId common.Hash, key []byte, startIndex, length uint64, inclusive bool, version ...uint64) (val *KeyValue, err error) {
+	return c.GetPrevContext(context.Background(), streamId, key, startIndex, length, inclusive, version...)
+}
+
+func (c *KvClient) GetPrevContext(ctx context.Context, streamId common.Hash, key []byte, startIndex, length uint64, inclusive bool, version ...uint64) (val *KeyValue, err error) {
 	args := []interface{}{streamId, key, startIndex, length, inclusive}
 	if len(version) > 0 {
 		args = append(args, version[0])
 	}
-	err = c.provider.CallContext(context.Background(), &val, "kv_getPrev", args...)
+	err = c.provider.CallContext(ctx, &val, "kv_getPrev", args...)
 	return
 }
 
 func (c *KvClient) GetFirst(streamId common.Hash, startIndex, length uint64, version ...uint64) (val *KeyValue, err error) {
+	return c.GetFirstContext(context.Background(), streamId, startIndex, length, version...)
+}
+
+func (c *KvClient) GetFirstContext(ctx context.Context, streamId common.Hash, startIndex, length uint64, version ...uint64) (val *KeyValue, err error) {
 	args := []interface{}{streamId, startIndex, length}
 	if len(version) > 0 {
 		args = append(args, version[0])
 	}
-	err = c.provider.CallContext(context.Background(), &val, "kv_getFirst", args...)
+	err = c.provider.CallContext(ctx, &val, "kv_getFirst", args...)
 	return
 }
 
 func (c *KvClient) GetLast(streamId common.Hash, startIndex, length uint64, version ...uint64) (val *KeyValue, err error) {
+	return c.GetLastContext(context.Background(), streamId, startIndex, length, version...)
+}
+
+func (c *KvClient) GetLastContext(ctx context.Context, streamId common.Hash, startIndex, length uint64, version ...uint64) (val *KeyValue, err error) {
 	args := []interface{}{streamId, startIndex, length}
 	if len(version) > 0 {
 		args = append(args, version[0])
 	}
-	err = c.provider.CallContext(context.Background(), &val, "kv_getLast", args...)
+	err = c.provider.CallContext(ctx, &val, "kv_getLast", args...)
 	return
 }
 
 func (c *KvClient) GetTransactionResult(txSeq uint64) (result string, err error) {
-	err = c.provider.CallContext(context.Background(), &result, "kv_getTransactionResult", txSeq)
+	return c.GetTransactionResultContext(context.Background(), txSeq)
+}
+
+func (c *KvClient) GetTransactionResultContext(ctx context.Context, txSeq uint64) (result string, err error) {
+	err = c.provider.CallContext(ctx, &result, "kv_getTransactionResult", txSeq)
 	return
 }
 
 func (c *KvClient) GetHoldingStreamIds() (streamIds []common.Hash, err error) {
-	err = c.provider.CallContext(context.Background(), &streamIds, "kv_getHoldingStreamIds")
+	return c.GetHoldingStreamIdsContext(context.Background())
+}
+
+func (c *KvClient) GetHoldingStreamIdsContext(ctx context.Context) (streamIds []common.Hash, err error) {
+	err = c.provider.CallContext(ctx, &streamIds, "kv_getHoldingStreamIds")
 	return
 }
 
 func (c *KvClient) HasWritePermission(account common.Address, streamId common.Hash, key []byte, version ...uint64) (hasPermission bool, err error) {
+	return c.HasWritePermissionContext(context.Background(), account, streamId, key, version...)
+}
+
+func (c *KvClient) HasWritePermissionContext(ctx context.Context, account common.Address, streamId common.Hash, key []byte, version ...uint64) (hasPermission bool, err error) {
 	args := []interface{}{account, streamId, key}
 	if len(version) > 0 {
 		args = append(args, version[0])
 	}
-	err = c.provider.CallContext(context.Background(), &hasPermission, "kv_hasWritePermission", args...)
+	err = c.provider.CallContext(ctx, &hasPermission, "kv_hasWritePermission", args...)
 	return
 }
 
 func (c *KvClient) IsAdmin(account common.Address, streamId common.Hash, version ...uint64) (isAdmin bool, err error) {
+	return c.IsAdminContext(context.Background(), account, streamId, version...)
+}
+
+func (c *KvClient) IsAdminContext(ctx context.Context, account common.Address, streamId common.Hash, version ...uint64) (isAdmin bool, err error) {
 	args := []interface{}{account, streamId}
 	if len(version) > 0 {
 		args = append(args, version[0])
 	}
-	err = c.provider.CallContext(context.Background(), &isAdmin, "kv_isAdmin", args...)
+	err = c.provider.CallContext(ctx, &isAdmin, "kv_isAdmin", args...)
 	return
 }
 
 func (c *KvClient) IsSpecialKey(streamId common.Hash, key []byte, version ...uint64) (isSpecialKey bool, err error) {
+	return c.IsSpecialKeyContext(context.Background(), streamId, key, version...)
+}
+
+func (c *KvClient) IsSpecialKeyContext(ctx context.Context, streamId common.Hash, key []byte, version ...uint64) (isSpecialKey bool, err error) {
 	args := []interface{}{streamId, key}
 	if len(version) > 0 {
 		args = append(args, version[0])
 	}
-	err = c.provider.CallContext(context.Background(), &isSpecialKey, "kv_isSpecialKey", args...)
+	err = c.provider.CallContext(ctx, &isSpecialKey, "kv_isSpecialKey", args...)
 	return
 }
 
 func (c *KvClient) IsWriterOfKey(account common.Address, streamId common.Hash, key []byte, version ...uint64) (isWriter bool, err error) {
+	return c.IsWriterOfKeyContext(context.Background(), account, streamId, key, version...)
+}
+
+func (c *KvClient) IsWriterOfKeyContext(ctx context.Context, account common.Address, streamId common.Hash, key []byte, version ...uint64) (isWriter bool, err error) {
 	args := []interface{}{account, streamId, key}
 	if len(version) > 0 {
 		args = append(args, version[0])
 	}
-	err = c.provider.CallContext(context.Background(), &isWriter, "kv_isWriterOfKey", args...)
+	err = c.provider.CallContext(ctx, &isWriter, "kv_isWriterOfKey", args...)
 	return
 }
 
 func (c *KvClient) IsWriterOfStream(account common.Address, streamId common.Hash, version ...uint64) (isWriter bool, err error) {
+	return c.IsWriterOfStreamContext(context.Background(), account, streamId, version...)
+}
+
+func (c *KvClient) IsWriterOfStreamContext(ctx context.Context, account common.Address, streamId common.Hash, version ...uint64) (isWriter bool, err error) {
 	args := []interface{}{account, streamId}
 	if len(version) > 0 {
 		args = append(args, version[0])
 	}
-	err = c.provider.CallContext(context.Background(), &isWriter, "kv_isWriterOfStream", args...)
+	err = c.provider.CallContext(ctx, &isWriter, "kv_isWriterOfStream", args...)
 	return
 }
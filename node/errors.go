@@ -0,0 +1,53 @@
+package node
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	rpc "github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+)
+
+// IsRetryableError returns whether the specified error of storage node RPC is temporary,
+// e.g. network error, timeout or HTTP 5xx, so that the request could be retried later.
+//
+// Otherwise, e.g. JSON RPC error returned by storage node, the request will always fail.
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// canceled by caller
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	// business error returned by storage node, e.g. invalid segment or proof
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return false
+	}
+
+	// HTTP error in format "<status code> <response body>"
+	if status, ok := parseHttpStatus(err.Error()); ok {
+		return status >= 500 || status == 408 || status == 429
+	}
+
+	// network error or timeout
+	return true
+}
+
+func parseHttpStatus(message string) (int, bool) {
+	fields := strings.Fields(message)
+	if len(fields) == 0 {
+		return 0, false
+	}
+
+	status, err := strconv.Atoi(fields[0])
+	if err != nil || status < 100 || status > 599 {
+		return 0, false
+	}
+
+	return status, true
+}
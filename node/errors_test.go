@@ -0,0 +1,34 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	rpc "github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	assert.False(t, IsRetryableError(nil))
+
+	// network error
+	assert.True(t, IsRetryableError(errors.New("dial tcp 127.0.0.1:5678: connect: connection refused")))
+	assert.True(t, IsRetryableError(context.DeadlineExceeded))
+
+	// canceled by caller
+	assert.False(t, IsRetryableError(context.Canceled))
+	assert.False(t, IsRetryableError(errors.WithMessage(context.Canceled, "Failed to upload segment")))
+
+	// HTTP status
+	assert.True(t, IsRetryableError(fmt.Errorf("%v %v", 502, "Bad Gateway")))
+	assert.True(t, IsRetryableError(fmt.Errorf("%v", 503)))
+	assert.True(t, IsRetryableError(fmt.Errorf("%v", 429)))
+	assert.False(t, IsRetryableError(fmt.Errorf("%v %v", 413, "Request Entity Too Large")))
+
+	// JSON RPC error
+	rpcErr := &rpc.JsonError{Code: -32000, Message: "invalid proof"}
+	assert.False(t, IsRetryableError(rpcErr))
+	assert.False(t, IsRetryableError(errors.WithMessage(rpcErr, "Failed to upload segment")))
+}